@@ -0,0 +1,60 @@
+package authenticater
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"sync"
+)
+
+// DefaultAPIKeyHeader is the header APIKeyAuth reads the key from by default.
+const DefaultAPIKeyHeader = "X-Api-Key"
+
+// APIKeyAuth ensures that a header, or optionally a query parameter, contains
+// a known API key and is safe for concurrent use.
+type APIKeyAuth struct {
+	sync.RWMutex
+	keys [][sha256.Size]byte
+
+	// HeaderName is the header holding the key.
+	HeaderName string
+
+	// QueryParam, if set, is a query parameter the key is read from when the
+	// header is absent, for webhook senders that can't set headers. Keys in
+	// URLs tend to end up in logs, so only enable it where needed.
+	QueryParam string
+}
+
+// NewAPIKeyAuth returns an empty APIKeyAuth reading DefaultAPIKeyHeader.
+func NewAPIKeyAuth() *APIKeyAuth {
+	return &APIKeyAuth{HeaderName: DefaultAPIKeyHeader}
+}
+
+// AddKey adds a key to the list of acceptable keys.
+func (aka *APIKeyAuth) AddKey(key string) {
+	aka.Lock()
+	aka.keys = append(aka.keys, sha256.Sum256([]byte(key)))
+	aka.Unlock()
+}
+
+// Authenticate the request if it carries a known key. The key is compared
+// against every known key in constant time.
+func (aka *APIKeyAuth) Authenticate(r *http.Request) bool {
+	key := r.Header.Get(aka.HeaderName)
+	if key == "" && aka.QueryParam != "" {
+		key = r.URL.Query().Get(aka.QueryParam)
+	}
+	if key == "" {
+		return false
+	}
+	sum := sha256.Sum256([]byte(key))
+
+	aka.RLock()
+	defer aka.RUnlock()
+
+	match := 0
+	for _, k := range aka.keys {
+		match |= subtle.ConstantTimeCompare(k[:], sum[:])
+	}
+	return match == 1
+}
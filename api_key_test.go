@@ -0,0 +1,53 @@
+package authenticater
+
+import "testing"
+
+func TestAPIKeyAuth(t *testing.T) {
+	aka := NewAPIKeyAuth()
+	aka.AddKey("key1")
+	aka.AddKey("key2")
+
+	tests := []struct {
+		name       string
+		header     string
+		value      string
+		url        string
+		queryParam string
+		ok         bool
+	}{
+		{"default header", "X-Api-Key", "key1", "/", "", true},
+		{"second key", "X-Api-Key", "key2", "/", "", true},
+		{"unknown key", "X-Api-Key", "key3", "/", "", false},
+		{"missing header", "", "", "/", "", false},
+		{"query param disabled", "", "", "/?api_key=key1", "", false},
+		{"query param", "", "", "/?api_key=key1", "api_key", true},
+		{"unknown query param key", "", "", "/?api_key=key3", "api_key", false},
+	}
+
+	for _, tt := range tests {
+		aka.QueryParam = tt.queryParam
+		r := newTestRequest(t, "GET", tt.url, "")
+		if tt.header != "" {
+			r.Header.Set(tt.header, tt.value)
+		}
+		if got := aka.Authenticate(r); got != tt.ok {
+			t.Errorf("%s: expected %t, got %t", tt.name, tt.ok, got)
+		}
+	}
+}
+
+func TestAPIKeyAuthHeaderName(t *testing.T) {
+	aka := NewAPIKeyAuth()
+	aka.HeaderName = "X-Service-Key"
+	aka.AddKey("key1")
+
+	r := newTestRequest(t, "GET", "/", "")
+	r.Header.Set("X-Api-Key", "key1")
+	if aka.Authenticate(r) {
+		t.Error("Expected the default header to be ignored")
+	}
+	r.Header.Set("X-Service-Key", "key1")
+	if !aka.Authenticate(r) {
+		t.Error("Expected the configured header to be used")
+	}
+}
@@ -1,19 +1,95 @@
 package authenticater
 
-import "net/http"
+import (
+	"context"
+	"net/http"
+)
 
 // Authenticater provides an interface for authentication of a http.Request
 type Authenticater interface {
 	Authenticate(r *http.Request) bool
 }
 
+// ErrAuthenticater is implemented by Authenticaters that depend on a backend
+// which can fail. AuthenticateErr returns a non-nil error when the request
+// couldn't be checked, as opposed to false with a nil error when its
+// credentials are invalid.
+type ErrAuthenticater interface {
+	AuthenticateErr(r *http.Request) (bool, error)
+}
+
+// authenticate uses AuthenticateErr if auth implements ErrAuthenticater and
+// Authenticate otherwise.
+func authenticate(auth Authenticater, r *http.Request) (bool, error) {
+	if ea, ok := auth.(ErrAuthenticater); ok {
+		return ea.AuthenticateErr(r)
+	}
+	return auth.Authenticate(r), nil
+}
+
+// ContextAuthenticater is implemented by Authenticaters that make what they
+// learn about a request, e.g. token claims, available to the handler.
+// AuthenticateContext returns the request's context with those values added.
+type ContextAuthenticater interface {
+	AuthenticateContext(r *http.Request) (context.Context, bool)
+}
+
+// AuthenticaterFunc is an adapter to allow the use of ordinary functions as
+// Authenticaters, in the same way http.HandlerFunc adapts functions to
+// http.Handler.
+type AuthenticaterFunc func(r *http.Request) bool
+
+// Authenticate calls f(r).
+func (f AuthenticaterFunc) Authenticate(r *http.Request) bool {
+	return f(r)
+}
+
+// Challenger is implemented by Authenticaters that can tell a client how to
+// authenticate. Challenge returns the value of the WWW-Authenticate header,
+// e.g. `Basic realm="Restricted"`.
+type Challenger interface {
+	Challenge() string
+}
+
+// RequestChallenger is implemented by Authenticaters whose challenge depends
+// on the request, e.g. on the area of the service it is for. WrapAuth prefers
+// it to Challenger.
+type RequestChallenger interface {
+	ChallengeRequest(r *http.Request) string
+}
+
 // WrapAuth returns a http.Handlerfunc that runs the passed Handlerfunc if and
-// only if the Authenticator can authenticate the request
+// only if the Authenticator can authenticate the request. Otherwise it
+// responds 401, with a WWW-Authenticate header if the Authenticator is a
+// RequestChallenger or Challenger, or 503 if it is an ErrAuthenticater that returned an error. If
+// the Authenticator is a ContextAuthenticater the handler is run with the
+// context it returned; if that context is nil it responds 500 instead. The
+// handler is never run for a request that isn't authenticated.
 func WrapAuth(auth Authenticater, handle http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if auth.Authenticate(r) {
-			handle(w, r)
+		var ok bool
+		var err error
+		var ctx context.Context
+		if ca, isCA := auth.(ContextAuthenticater); isCA {
+			ctx, ok = ca.AuthenticateContext(r)
 		} else {
+			ctx = r.Context()
+			ok, err = authenticate(auth, r)
+		}
+
+		switch {
+		case err != nil:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case ok && ctx == nil:
+			w.WriteHeader(http.StatusInternalServerError)
+		case ok:
+			handle(w, r.WithContext(ctx))
+		default:
+			if rc, ok := auth.(RequestChallenger); ok {
+				w.Header().Set("WWW-Authenticate", rc.ChallengeRequest(r))
+			} else if c, ok := auth.(Challenger); ok {
+				w.Header().Set("WWW-Authenticate", c.Challenge())
+			}
 			w.WriteHeader(http.StatusUnauthorized)
 		}
 	}
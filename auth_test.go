@@ -0,0 +1,202 @@
+package authenticater
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newTestRequest returns a request for target, failing the test if it can't
+// be constructed. An empty body sends none.
+func newTestRequest(t *testing.T, method, target, body string) *http.Request {
+	var b io.Reader
+	if body != "" {
+		b = strings.NewReader(body)
+	}
+	r, err := http.NewRequest(method, target, b)
+	if err != nil {
+		t.Fatalf("Unable to construct sample request: %s", err)
+	}
+	return r
+}
+
+func serveWrapped(t *testing.T, auth Authenticater, setup func(r *http.Request)) (*httptest.ResponseRecorder, bool) {
+	called := false
+	h := WrapAuth(auth, func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.Write([]byte("Hello"))
+	})
+
+	r := newTestRequest(t, "GET", "/", "")
+	if setup != nil {
+		setup(r)
+	}
+	w := httptest.NewRecorder()
+	h(w, r)
+	return w, called
+}
+
+func TestWrapAuthBasicAuthRealm(t *testing.T) {
+	tests := []struct {
+		realm     string
+		challenge string
+	}{
+		{"", `Basic realm="Restricted"`},
+		{"Billing Admin", `Basic realm="Billing Admin"`},
+		{`Say "hi" \o/`, `Basic realm="Say \"hi\" \\o/"`},
+	}
+
+	for _, tt := range tests {
+		ba, err := NewBasicAuthFromString("foo:bar")
+		if err != nil {
+			t.Fatalf("Unable to construct basic auth checker: %s", err)
+		}
+		ba.Realm = tt.realm
+
+		w, called := serveWrapped(t, ba, nil)
+		if called || w.Code != http.StatusUnauthorized {
+			t.Errorf("realm %q: expected 401 without calling the handler, got %d (called = %t)", tt.realm, w.Code, called)
+		}
+		if got := w.Header().Get("WWW-Authenticate"); got != tt.challenge {
+			t.Errorf("realm %q: expected challenge %s, got %s", tt.realm, tt.challenge, got)
+		}
+
+		w, called = serveWrapped(t, ba, func(r *http.Request) { r.SetBasicAuth("foo", "bar") })
+		if !called || w.Code != http.StatusOK {
+			t.Errorf("realm %q: expected authenticated request to be handled, got %d", tt.realm, w.Code)
+		}
+		if got := w.Header().Get("WWW-Authenticate"); got != "" {
+			t.Errorf("realm %q: expected no challenge on success, got %s", tt.realm, got)
+		}
+	}
+}
+
+func TestWrapAuthWithoutChallenger(t *testing.T) {
+	w, called := serveWrapped(t, NewLogplexDrainToken(), nil)
+	if called || w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without calling the handler, got %d (called = %t)", w.Code, called)
+	}
+	if got, exists := w.Header()["Www-Authenticate"]; exists {
+		t.Errorf("Expected no WWW-Authenticate header, got %v", got)
+	}
+
+	w, called = serveWrapped(t, AnyOrNoAuth{}, nil)
+	if !called || w.Code != http.StatusOK {
+		t.Errorf("Expected AnyOrNoAuth to let the request through, got %d", w.Code)
+	}
+}
+
+func TestAuthenticaterFunc(t *testing.T) {
+	auth := AuthenticaterFunc(func(r *http.Request) bool {
+		return r.Header.Get("X-Internal") == "yes"
+	})
+
+	w, called := serveWrapped(t, auth, nil)
+	if called || w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without the header, got %d (called = %t)", w.Code, called)
+	}
+
+	w, called = serveWrapped(t, auth, func(r *http.Request) { r.Header.Set("X-Internal", "yes") })
+	if !called || w.Code != http.StatusOK {
+		t.Errorf("Expected the request with the header to be handled, got %d", w.Code)
+	}
+}
+
+// errAuth is an ErrAuthenticater returning fixed results.
+type errAuth struct {
+	ok  bool
+	err error
+}
+
+func (e errAuth) Authenticate(r *http.Request) bool {
+	return e.ok && e.err == nil
+}
+
+func (e errAuth) AuthenticateErr(r *http.Request) (bool, error) {
+	return e.ok, e.err
+}
+
+func TestWrapAuthErrAuthenticater(t *testing.T) {
+	tests := []struct {
+		name   string
+		auth   errAuth
+		status int
+		called bool
+	}{
+		{"authenticated", errAuth{ok: true}, http.StatusOK, true},
+		{"invalid credentials", errAuth{}, http.StatusUnauthorized, false},
+		{"backend down", errAuth{err: errors.New("backend down")}, http.StatusServiceUnavailable, false},
+	}
+
+	for _, tt := range tests {
+		w, called := serveWrapped(t, tt.auth, nil)
+		if w.Code != tt.status || called != tt.called {
+			t.Errorf("%s: expected %d (called = %t), got %d (called = %t)", tt.name, tt.status, tt.called, w.Code, called)
+		}
+	}
+}
+
+type testContextKey struct{}
+
+// ctxAuth is a ContextAuthenticater returning fixed results, adding
+// testContextKey to the context when ok unless nilCtx is set.
+type ctxAuth struct {
+	ok     bool
+	nilCtx bool
+}
+
+func (c ctxAuth) Authenticate(r *http.Request) bool {
+	return c.ok
+}
+
+func (c ctxAuth) AuthenticateContext(r *http.Request) (context.Context, bool) {
+	if c.nilCtx {
+		return nil, c.ok
+	}
+	if !c.ok {
+		return r.Context(), false
+	}
+	return context.WithValue(r.Context(), testContextKey{}, "principal"), true
+}
+
+func TestWrapAuthNeverCallsHandlerOnDenial(t *testing.T) {
+	ja := NewJWTAuthHMAC([]byte("secret"))
+
+	tests := []struct {
+		name   string
+		auth   Authenticater
+		status int
+	}{
+		{"denied", Not(AnyOrNoAuth{}), http.StatusUnauthorized},
+		{"error", errAuth{ok: true, err: errors.New("backend down")}, http.StatusServiceUnavailable},
+		{"context denied", ctxAuth{}, http.StatusUnauthorized},
+		{"context denied with nil context", ctxAuth{nilCtx: true}, http.StatusUnauthorized},
+		{"context allowed with nil context", ctxAuth{ok: true, nilCtx: true}, http.StatusInternalServerError},
+		{"JWT without token", ja, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		w, called := serveWrapped(t, tt.auth, nil)
+		if called || w.Code != tt.status {
+			t.Errorf("%s: expected %d without calling the handler, got %d (called = %t)", tt.name, tt.status, w.Code, called)
+		}
+	}
+}
+
+func TestWrapAuthContextOnSuccess(t *testing.T) {
+	var value interface{}
+	h := WrapAuth(ctxAuth{ok: true}, func(w http.ResponseWriter, r *http.Request) {
+		value = r.Context().Value(testContextKey{})
+	})
+
+	r := newTestRequest(t, "GET", "/", "")
+	w := httptest.NewRecorder()
+	h(w, r)
+	if w.Code != http.StatusOK || value != "principal" {
+		t.Errorf("Expected the handler to see the context value, got %d %v", w.Code, value)
+	}
+}
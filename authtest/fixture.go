@@ -0,0 +1,130 @@
+// Package authtest provides helpers for testing Authenticaters against
+// recorded request fixtures.
+package authtest
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/heroku/authenticater"
+)
+
+// Fixture is a recorded request and the authentication decision expected for
+// it. Fixtures are stored as JSON, e.g.
+//
+//	{
+//	  "name": "valid basic auth",
+//	  "method": "GET",
+//	  "url": "https://example.com/admin",
+//	  "headers": {"Authorization": "Basic Zm9vOmJhcg=="},
+//	  "tls": true,
+//	  "expect": true
+//	}
+type Fixture struct {
+	Name       string            `json:"name"`
+	Method     string            `json:"method"`
+	URL        string            `json:"url"`
+	RemoteAddr string            `json:"remote_addr"`
+	Headers    map[string]string `json:"headers"`
+	Body       string            `json:"body"`
+	TLS        bool              `json:"tls"`
+	Expect     bool              `json:"expect"`
+}
+
+// LoadFixture reads a Fixture from a JSON file. The file name is used as the
+// fixture's name if it doesn't have one.
+func LoadFixture(path string) (*Fixture, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Fixture{Method: "GET"}
+	if err := json.Unmarshal(b, f); err != nil {
+		return nil, fmt.Errorf("Unable to parse fixture %s: %s", path, err)
+	}
+	if f.URL == "" {
+		return nil, fmt.Errorf("Unable to parse fixture %s: missing url", path)
+	}
+	if _, err := f.request(); err != nil {
+		return nil, fmt.Errorf("Unable to parse fixture %s: %s", path, err)
+	}
+	if f.Name == "" {
+		f.Name = filepath.Base(path)
+	}
+	return f, nil
+}
+
+// LoadFixtures reads every Fixture matching the glob pattern, e.g.
+// "testdata/*.json".
+func LoadFixtures(pattern string) ([]*Fixture, error) {
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	fixtures := make([]*Fixture, 0, len(paths))
+	for _, path := range paths {
+		f, err := LoadFixture(path)
+		if err != nil {
+			return nil, err
+		}
+		fixtures = append(fixtures, f)
+	}
+	return fixtures, nil
+}
+
+// Request builds the fixture's http.Request. Like httptest.NewRequest it
+// panics if the method or URL is invalid, which LoadFixture rules out.
+func (f *Fixture) Request() *http.Request {
+	r, err := f.request()
+	if err != nil {
+		panic(fmt.Sprintf("authtest: fixture %s: %s", f.Name, err))
+	}
+	return r
+}
+
+func (f *Fixture) request() (*http.Request, error) {
+	var body io.Reader
+	if f.Body != "" {
+		body = bytes.NewBufferString(f.Body)
+	}
+
+	r, err := http.NewRequest(f.Method, f.URL, body)
+	if err != nil {
+		return nil, err
+	}
+	r.RemoteAddr = "192.0.2.1:1234"
+	if f.RemoteAddr != "" {
+		r.RemoteAddr = f.RemoteAddr
+	}
+	if r.Host == "" {
+		r.Host = "example.com"
+	}
+	for k, v := range f.Headers {
+		r.Header.Set(k, v)
+	}
+	if f.TLS {
+		r.TLS = &tls.ConnectionState{}
+	}
+	return r, nil
+}
+
+// RunFixture authenticates the fixture's request with auth, returning an error
+// if the decision isn't the expected one.
+func RunFixture(auth authenticater.Authenticater, f *Fixture) error {
+	r, err := f.request()
+	if err != nil {
+		return fmt.Errorf("Fixture %s: %s", f.Name, err)
+	}
+	if got := auth.Authenticate(r); got != f.Expect {
+		return fmt.Errorf("Fixture %s: expected %t, got %t", f.Name, f.Expect, got)
+	}
+	return nil
+}
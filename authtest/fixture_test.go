@@ -0,0 +1,73 @@
+package authtest
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/heroku/authenticater"
+)
+
+func TestRunFixtures(t *testing.T) {
+	ba, err := authenticater.NewBasicAuthFromString("foo:bar")
+	if err != nil {
+		t.Fatalf("Unable to construct basic auth checker: %s", err)
+	}
+	requireTLS := authenticater.AuthenticaterFunc(func(r *http.Request) bool { return r.TLS != nil })
+	auth := authenticater.And(requireTLS, ba)
+
+	fixtures, err := LoadFixtures("testdata/*.json")
+	if err != nil {
+		t.Fatalf("Unable to load fixtures: %s", err)
+	}
+	if len(fixtures) != 3 {
+		t.Fatalf("Expected 3 fixtures, got %d", len(fixtures))
+	}
+
+	for _, f := range fixtures {
+		if err := RunFixture(auth, f); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestRunFixtureMismatch(t *testing.T) {
+	f, err := LoadFixture("testdata/basic_valid.json")
+	if err != nil {
+		t.Fatalf("Unable to load fixture: %s", err)
+	}
+	if f.Name != "valid basic auth over TLS" || f.RemoteAddr != "10.0.0.1:41234" || !f.TLS {
+		t.Errorf("Unexpected fixture contents: %+v", f)
+	}
+
+	deny := authenticater.AuthenticaterFunc(func(r *http.Request) bool { return false })
+	if err := RunFixture(deny, f); err == nil {
+		t.Error("Expected an error when the decision doesn't match")
+	}
+}
+
+func TestLoadFixtureErrors(t *testing.T) {
+	dir := t.TempDir()
+	for name, contents := range map[string]string{
+		"bad.json":        "{",
+		"no_url.json":     `{"method": "GET"}`,
+		"bad_url.json":    `{"url": "http://[::1"}`,
+		"bad_method.json": `{"method": "GE T", "url": "/"}`,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+			t.Fatalf("Unable to write fixture: %s", err)
+		}
+		if _, err := LoadFixture(path); err == nil {
+			t.Errorf("Expected an error loading %s", name)
+		}
+	}
+}
+
+func TestRunFixtureInvalid(t *testing.T) {
+	f := &Fixture{Name: "invalid", Method: "GET", URL: "http://[::1"}
+	if err := RunFixture(authenticater.AnyOrNoAuth{}, f); err == nil {
+		t.Error("Expected an error for a fixture with an invalid URL")
+	}
+}
@@ -0,0 +1,172 @@
+package authenticater
+
+import (
+	"crypto/sha256"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// DefaultRedisCacheTTL is how long BasicAuthRedis caches a user's hash.
+	DefaultRedisCacheTTL = 30 * time.Second
+
+	// DefaultRedisCacheSize is how many users BasicAuthRedis caches.
+	DefaultRedisCacheSize = 10000
+)
+
+// RedisGetter is the part of a Redis client used by BasicAuthRedis. Wrap the
+// client of your choice to implement it.
+type RedisGetter interface {
+	// Get returns the string stored at key. found is false, with a nil error,
+	// if the key does not exist.
+	Get(key string) (value string, found bool, err error)
+}
+
+// RedisFailPolicy decides how BasicAuthRedis treats Redis errors.
+type RedisFailPolicy int
+
+const (
+	// RedisFailClosed denies requests when Redis can't be queried.
+	RedisFailClosed RedisFailPolicy = iota
+
+	// RedisFailStale verifies against an expired cache entry for the user,
+	// if there is one, when Redis can't be queried and denies otherwise.
+	RedisFailStale
+)
+
+// BasicAuthRedis handles Basic Auth requests by checking the password against
+// a bcrypt hash stored in Redis at keyPrefix+username. Hashes, and the absence
+// of one, are cached in-process for CacheTTL. It is safe for concurrent use.
+type BasicAuthRedis struct {
+	sync.Mutex
+	client    RedisGetter
+	keyPrefix string
+
+	// CacheTTL is how long a user's hash is cached.
+	CacheTTL time.Duration
+
+	// CacheSize caps the number of cached users, including ones without a
+	// hash. When the cache is full expired entries are dropped, and then an
+	// arbitrary entry if it is still full. Zero disables caching.
+	CacheSize int
+
+	// FailPolicy decides what happens when Redis returns an error.
+	FailPolicy RedisFailPolicy
+
+	cache map[[sha256.Size]byte]redisHash
+	now   func() time.Time
+
+	// dummy is the costliest hash fetched so far, which users without a hash
+	// are checked against so they take as long to reject.
+	dummy     []byte
+	dummyCost int
+}
+
+type redisHash struct {
+	hash    []byte
+	expires time.Time
+}
+
+// NewBasicAuthRedis returns a BasicAuthRedis reading hashes from client under
+// keyPrefix, failing closed.
+func NewBasicAuthRedis(client RedisGetter, keyPrefix string) *BasicAuthRedis {
+	return &BasicAuthRedis{
+		client:    client,
+		keyPrefix: keyPrefix,
+		CacheTTL:  DefaultRedisCacheTTL,
+		CacheSize: DefaultRedisCacheSize,
+		cache:     make(map[[sha256.Size]byte]redisHash),
+		now:       time.Now,
+	}
+}
+
+// Authenticate is true if the Request has a valid BasicAuth signature whose
+// password matches the hash stored for the user.
+func (bar *BasicAuthRedis) Authenticate(r *http.Request) bool {
+	ok, err := bar.AuthenticateErr(r)
+	return ok && err == nil
+}
+
+// AuthenticateErr is like Authenticate but returns an error if Redis couldn't
+// be queried and FailPolicy provided no fallback.
+func (bar *BasicAuthRedis) AuthenticateErr(r *http.Request) (bool, error) {
+	user, pass, ok := r.BasicAuth()
+	if !ok {
+		return false, nil
+	}
+
+	hash, ok, err := bar.hash(user)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		bar.Lock()
+		dummy := bar.dummy
+		bar.Unlock()
+		if dummy == nil {
+			dummy = dummyHash
+		}
+		bcrypt.CompareHashAndPassword(dummy, []byte(pass))
+		return false, nil
+	}
+	return bcrypt.CompareHashAndPassword(hash, []byte(pass)) == nil, nil
+}
+
+// hash returns the user's hash from the cache or Redis.
+func (bar *BasicAuthRedis) hash(user string) ([]byte, bool, error) {
+	now := bar.now()
+	key := sha256.Sum256([]byte(user))
+
+	bar.Lock()
+	cached, exists := bar.cache[key]
+	bar.Unlock()
+	if exists && now.Before(cached.expires) {
+		return cached.hash, cached.hash != nil, nil
+	}
+
+	value, found, err := bar.client.Get(bar.keyPrefix + user)
+	if err != nil {
+		if bar.FailPolicy == RedisFailStale && exists {
+			return cached.hash, cached.hash != nil, nil
+		}
+		return nil, false, err
+	}
+
+	var hash []byte
+	if found {
+		hash = []byte(value)
+	}
+
+	bar.Lock()
+	if cost, err := bcrypt.Cost(hash); err == nil && cost > bar.dummyCost {
+		bar.dummy, bar.dummyCost = hash, cost
+	}
+	bar.store(key, redisHash{hash: hash, expires: now.Add(bar.CacheTTL)}, now)
+	bar.Unlock()
+	return hash, hash != nil, nil
+}
+
+// store caches entry under key, making room if the cache is full. The caller
+// must hold the lock.
+func (bar *BasicAuthRedis) store(key [sha256.Size]byte, entry redisHash, now time.Time) {
+	if bar.CacheSize <= 0 {
+		return
+	}
+	if _, exists := bar.cache[key]; !exists && len(bar.cache) >= bar.CacheSize {
+		for k, e := range bar.cache {
+			if !now.Before(e.expires) {
+				delete(bar.cache, k)
+			}
+		}
+		for k := range bar.cache {
+			if len(bar.cache) < bar.CacheSize {
+				break
+			}
+			delete(bar.cache, k)
+		}
+	}
+	bar.cache[key] = entry
+}
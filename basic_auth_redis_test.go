@@ -0,0 +1,163 @@
+package authenticater
+
+import (
+	"crypto/sha256"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type fakeRedis struct {
+	sync.Mutex
+	values map[string]string
+	err    error
+	gets   int
+}
+
+func (f *fakeRedis) Get(key string) (string, bool, error) {
+	f.Lock()
+	defer f.Unlock()
+	f.gets++
+	if f.err != nil {
+		return "", false, f.err
+	}
+	v, found := f.values[key]
+	return v, found, nil
+}
+
+func newFakeRedis(t *testing.T, creds map[string]string) *fakeRedis {
+	f := &fakeRedis{values: make(map[string]string)}
+	for user, pass := range creds {
+		hash, err := bcrypt.GenerateFromPassword([]byte(pass), bcrypt.MinCost)
+		if err != nil {
+			t.Fatalf("Unable to hash password: %s", err)
+		}
+		f.values["creds:"+user] = string(hash)
+	}
+	return f
+}
+
+func TestBasicAuthRedis(t *testing.T) {
+	redis := newFakeRedis(t, map[string]string{"foo": "bar"})
+	bar := NewBasicAuthRedis(redis, "creds:")
+
+	tests := []struct {
+		user, pass string
+		ok         bool
+	}{
+		{"foo", "bar", true},
+		{"foo", "baz", false},
+		{"missing", "bar", false},
+	}
+
+	for _, tt := range tests {
+		if got := basicAuthOK(t, bar, tt.user, tt.pass); got != tt.ok {
+			t.Errorf("%s/%s: expected %t, got %t", tt.user, tt.pass, tt.ok, got)
+		}
+	}
+}
+
+func TestBasicAuthRedisDummyCost(t *testing.T) {
+	redis := newFakeRedis(t, map[string]string{"foo": "bar"})
+	slow, err := bcrypt.GenerateFromPassword([]byte("bar"), bcrypt.MinCost+1)
+	if err != nil {
+		t.Fatalf("Unable to hash password: %s", err)
+	}
+	redis.values["creds:slow"] = string(slow)
+	bar := NewBasicAuthRedis(redis, "creds:")
+
+	for _, user := range []string{"slow", "foo", "missing"} {
+		basicAuthOK(t, bar, user, "bar")
+	}
+	if string(bar.dummy) != string(slow) {
+		t.Errorf("Expected unknown users to be checked against the costliest hash %s, got %s", slow, bar.dummy)
+	}
+}
+
+func TestBasicAuthRedisCache(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	redis := newFakeRedis(t, map[string]string{"foo": "bar"})
+	bar := NewBasicAuthRedis(redis, "creds:")
+	bar.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		basicAuthOK(t, bar, "foo", "bar")
+		basicAuthOK(t, bar, "missing", "bar")
+	}
+	if redis.gets != 2 {
+		t.Errorf("Expected hashes to be cached, got %d Redis lookups", redis.gets)
+	}
+
+	now = now.Add(DefaultRedisCacheTTL)
+	basicAuthOK(t, bar, "foo", "bar")
+	if redis.gets != 3 {
+		t.Errorf("Expected the cache entry to expire, got %d Redis lookups", redis.gets)
+	}
+}
+
+func TestBasicAuthRedisErrors(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	redis := newFakeRedis(t, map[string]string{"foo": "bar"})
+	bar := NewBasicAuthRedis(redis, "creds:")
+	bar.now = func() time.Time { return now }
+
+	if !basicAuthOK(t, bar, "foo", "bar") {
+		t.Fatal("Expected valid credentials to be accepted")
+	}
+
+	now = now.Add(DefaultRedisCacheTTL)
+	redis.err = errors.New("connection refused")
+	if basicAuthOK(t, bar, "foo", "bar") {
+		t.Error("Expected Redis errors to deny when failing closed")
+	}
+	r := newTestRequest(t, "GET", "/", "")
+	r.SetBasicAuth("foo", "bar")
+	if _, err := bar.AuthenticateErr(r); err == nil {
+		t.Error("Expected the Redis error to be returned when failing closed")
+	}
+
+	bar.FailPolicy = RedisFailStale
+	if !basicAuthOK(t, bar, "foo", "bar") {
+		t.Error("Expected the stale hash to be used when failing stale")
+	}
+	if basicAuthOK(t, bar, "uncached", "bar") {
+		t.Error("Expected users without a stale hash to be denied")
+	}
+}
+
+func TestBasicAuthRedisCacheSize(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	redis := newFakeRedis(t, map[string]string{"foo": "bar"})
+	bar := NewBasicAuthRedis(redis, "creds:")
+	bar.now = func() time.Time { return now }
+	bar.CacheSize = 3
+
+	for i := 0; i < 3; i++ {
+		basicAuthOK(t, bar, "user"+strconv.Itoa(i), "bar")
+	}
+	now = now.Add(DefaultRedisCacheTTL)
+	basicAuthOK(t, bar, "foo", "bar")
+	if n := len(bar.cache); n != 1 {
+		t.Errorf("Expected expired entries to be swept when full, got %d entries", n)
+	}
+
+	for i := 0; i < 10; i++ {
+		basicAuthOK(t, bar, "attacker"+strconv.Itoa(i), "bar")
+	}
+	if n := len(bar.cache); n != bar.CacheSize {
+		t.Errorf("Expected the cache to be capped at %d entries, got %d", bar.CacheSize, n)
+	}
+
+	bar.CacheSize = 0
+	bar.cache = make(map[[sha256.Size]byte]redisHash)
+	gets := redis.gets
+	basicAuthOK(t, bar, "foo", "bar")
+	basicAuthOK(t, bar, "foo", "bar")
+	if len(bar.cache) != 0 || redis.gets != gets+2 {
+		t.Errorf("Expected a zero CacheSize to disable caching, got %d entries and %d lookups", len(bar.cache), redis.gets-gets)
+	}
+}
@@ -0,0 +1,81 @@
+package authenticater
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RefreshingBasicAuth is a BasicAuth whose credentials are periodically
+// replaced with those returned by a fetch function, e.g. one reading from a
+// secrets manager. If a fetch fails the last successfully fetched credentials
+// remain in use.
+type RefreshingBasicAuth struct {
+	*BasicAuth
+	fetch     func() (map[string]string, error)
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewRefreshingBasicAuth fetches the initial user/password credentials and
+// starts a goroutine refreshing them every interval until Close is called.
+// An error is returned if interval is not positive or the initial fetch fails.
+func NewRefreshingBasicAuth(fetch func() (map[string]string, error), interval time.Duration) (*RefreshingBasicAuth, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("Invalid refresh interval: %s", interval)
+	}
+
+	rba := &RefreshingBasicAuth{
+		BasicAuth: NewBasicAuth(),
+		fetch:     fetch,
+		done:      make(chan struct{}),
+	}
+	if err := rba.refresh(); err != nil {
+		return nil, err
+	}
+
+	go rba.run(interval)
+	return rba, nil
+}
+
+func (rba *RefreshingBasicAuth) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			rba.refresh()
+		case <-rba.done:
+			return
+		}
+	}
+}
+
+// refresh atomically replaces the credentials with freshly fetched ones,
+// leaving them untouched if the fetch fails.
+func (rba *RefreshingBasicAuth) refresh() error {
+	creds, err := rba.fetch()
+	if err != nil {
+		return err
+	}
+
+	c := make(map[[sha256.Size]byte]*principal, len(creds))
+	for user, pass := range creds {
+		p := &principal{}
+		p.addPassword(pass)
+		c[sha256.Sum256([]byte(user))] = p
+	}
+
+	rba.Lock()
+	rba.creds = c
+	rba.Unlock()
+	return nil
+}
+
+// Close stops refreshing the credentials. The last fetched credentials are
+// still used to authenticate requests.
+func (rba *RefreshingBasicAuth) Close() error {
+	rba.closeOnce.Do(func() { close(rba.done) })
+	return nil
+}
@@ -0,0 +1,113 @@
+package authenticater
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeCredsSource struct {
+	sync.Mutex
+	creds map[string]string
+	err   error
+	calls int
+}
+
+func (f *fakeCredsSource) fetch() (map[string]string, error) {
+	f.Lock()
+	defer f.Unlock()
+	f.calls++
+	return f.creds, f.err
+}
+
+func (f *fakeCredsSource) set(creds map[string]string, err error) {
+	f.Lock()
+	f.creds, f.err = creds, err
+	f.Unlock()
+}
+
+func (f *fakeCredsSource) callCount() int {
+	f.Lock()
+	defer f.Unlock()
+	return f.calls
+}
+
+func TestRefreshingBasicAuthRefresh(t *testing.T) {
+	src := &fakeCredsSource{creds: map[string]string{"foo": "bar"}}
+	rba, err := NewRefreshingBasicAuth(src.fetch, time.Hour)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer rba.Close()
+
+	if !basicAuthOK(t, rba, "foo", "bar") {
+		t.Fatal("Expected initial credentials to be accepted")
+	}
+
+	src.set(map[string]string{"foo": "baz"}, nil)
+	if err := rba.refresh(); err != nil {
+		t.Fatalf("Unexpected refresh error: %s", err)
+	}
+	if basicAuthOK(t, rba, "foo", "bar") {
+		t.Error("Expected replaced credentials to be rejected")
+	}
+	if !basicAuthOK(t, rba, "foo", "baz") {
+		t.Error("Expected refreshed credentials to be accepted")
+	}
+
+	src.set(nil, errors.New("secrets manager unavailable"))
+	if err := rba.refresh(); err == nil {
+		t.Fatal("Expected refresh error")
+	}
+	if !basicAuthOK(t, rba, "foo", "baz") {
+		t.Error("Expected last known good credentials to be kept after a failed refresh")
+	}
+}
+
+func TestRefreshingBasicAuthInitialError(t *testing.T) {
+	src := &fakeCredsSource{err: errors.New("secrets manager unavailable")}
+	if _, err := NewRefreshingBasicAuth(src.fetch, time.Hour); err == nil {
+		t.Error("Expected an error when the initial fetch fails")
+	}
+}
+
+func TestRefreshingBasicAuthInvalidInterval(t *testing.T) {
+	src := &fakeCredsSource{creds: map[string]string{"foo": "bar"}}
+	for _, interval := range []time.Duration{0, -time.Second} {
+		if _, err := NewRefreshingBasicAuth(src.fetch, interval); err == nil {
+			t.Errorf("Expected an error for interval %s", interval)
+		}
+	}
+	if n := src.callCount(); n != 0 {
+		t.Errorf("Expected no fetch for an invalid interval, got %d", n)
+	}
+}
+
+func TestRefreshingBasicAuthClose(t *testing.T) {
+	src := &fakeCredsSource{creds: map[string]string{"foo": "bar"}}
+	rba, err := NewRefreshingBasicAuth(src.fetch, time.Millisecond)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for src.callCount() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected credentials to be refreshed in the background")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	rba.Close()
+	rba.Close()
+	time.Sleep(5 * time.Millisecond)
+	calls := src.callCount()
+	time.Sleep(20 * time.Millisecond)
+	if src.callCount() != calls {
+		t.Error("Expected no refreshes after Close")
+	}
+	if !basicAuthOK(t, rba, "foo", "bar") {
+		t.Error("Expected credentials to remain usable after Close")
+	}
+}
@@ -0,0 +1,46 @@
+package authenticater
+
+import (
+	"net/http"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestBasicAuthResolver(t *testing.T) {
+	tenants := make(map[string][2]string)
+	for host, creds := range map[string][2]string{
+		"acme.example.com":   {"acme", "anvil"},
+		"globex.example.com": {"globex", "hank"},
+	} {
+		hash, err := bcrypt.GenerateFromPassword([]byte(creds[1]), bcrypt.MinCost)
+		if err != nil {
+			t.Fatalf("Unable to hash password: %s", err)
+		}
+		tenants[host] = [2]string{creds[0], string(hash)}
+	}
+
+	bar := NewBasicAuthResolver(func(r *http.Request) (string, string, bool) {
+		creds, ok := tenants[r.Host]
+		return creds[0], creds[1], ok
+	})
+
+	tests := []struct {
+		host, user, pass string
+		ok               bool
+	}{
+		{"acme.example.com", "acme", "anvil", true},
+		{"globex.example.com", "globex", "hank", true},
+		{"acme.example.com", "globex", "hank", false},
+		{"globex.example.com", "globex", "anvil", false},
+		{"initech.example.com", "acme", "anvil", false},
+	}
+
+	for _, tt := range tests {
+		r := newTestRequest(t, "GET", "http://"+tt.host+"/", "")
+		r.SetBasicAuth(tt.user, tt.pass)
+		if got := bar.Authenticate(r); got != tt.ok {
+			t.Errorf("%s %s/%s: expected %t, got %t", tt.host, tt.user, tt.pass, tt.ok, got)
+		}
+	}
+}
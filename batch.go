@@ -0,0 +1,49 @@
+package authenticater
+
+import "net/http"
+
+// BatchAuthenticater is implemented by Authenticaters that can check many
+// requests at once more cheaply than one at a time, for example by sharing a
+// single connection to a backing service across the batch.
+type BatchAuthenticater interface {
+	Authenticater
+
+	// AuthenticateBatch returns whether each of rs authenticates, in the
+	// same order as rs.
+	AuthenticateBatch(rs []*http.Request) []bool
+}
+
+// AuthenticateBatch returns whether each of rs authenticates with auth, in
+// the same order as rs. It uses auth's AuthenticateBatch if it's a
+// BatchAuthenticater and otherwise checks the requests one at a time. A
+// request whose check fails with an error doesn't authenticate.
+func AuthenticateBatch(auth Authenticater, rs []*http.Request) []bool {
+	if ba, ok := auth.(BatchAuthenticater); ok {
+		return ba.AuthenticateBatch(rs)
+	}
+	results := make([]bool, len(rs))
+	for i, r := range rs {
+		ok, err := authenticate(auth, r)
+		results[i] = ok && err == nil
+	}
+	return results
+}
+
+// batchWhere hands the requests of rs for which pending is true to auth as a
+// single batch and calls set with the index in rs and result of each.
+func batchWhere(auth Authenticater, rs []*http.Request, pending []bool, set func(i int, ok bool)) {
+	var sub []*http.Request
+	var idx []int
+	for i, r := range rs {
+		if pending[i] {
+			sub = append(sub, r)
+			idx = append(idx, i)
+		}
+	}
+	if len(sub) == 0 {
+		return
+	}
+	for j, ok := range AuthenticateBatch(auth, sub) {
+		set(idx[j], ok)
+	}
+}
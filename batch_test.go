@@ -0,0 +1,88 @@
+package authenticater
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// batchAuth authenticates requests whose path is in allowed and records the
+// size of each batch it's asked to check.
+type batchAuth struct {
+	allowed map[string]bool
+	batches []int
+}
+
+func (b *batchAuth) Authenticate(r *http.Request) bool {
+	return b.AuthenticateBatch([]*http.Request{r})[0]
+}
+
+func (b *batchAuth) AuthenticateBatch(rs []*http.Request) []bool {
+	b.batches = append(b.batches, len(rs))
+	results := make([]bool, len(rs))
+	for i, r := range rs {
+		results[i] = b.allowed[r.URL.Path]
+	}
+	return results
+}
+
+func newBatch(t *testing.T, paths ...string) []*http.Request {
+	rs := make([]*http.Request, len(paths))
+	for i, path := range paths {
+		rs[i] = newTestRequest(t, "GET", path, "")
+	}
+	return rs
+}
+
+func TestAuthenticateBatch(t *testing.T) {
+	rs := newBatch(t, "/a", "/b", "/c", "/d")
+	first := &batchAuth{allowed: map[string]bool{"/a": true, "/b": true}}
+	second := &batchAuth{allowed: map[string]bool{"/b": true, "/c": true}}
+
+	tests := []struct {
+		name    string
+		auth    Authenticater
+		ok      []bool
+		batches [][]int
+	}{
+		{"And", And(first, second), []bool{false, true, false, false}, [][]int{{4}, {2}}},
+		{"Or", Or(first, second), []bool{true, true, true, false}, [][]int{{4}, {2}}},
+		{"Not", Not(first), []bool{false, false, true, true}, [][]int{{1, 1, 1, 1}, nil}},
+	}
+
+	for _, tt := range tests {
+		first.batches, second.batches = nil, nil
+
+		got := AuthenticateBatch(tt.auth, rs)
+		for i, ok := range tt.ok {
+			if got[i] != ok {
+				t.Errorf("%s: expected request %d to be %t, got %t", tt.name, i, ok, got[i])
+			}
+		}
+		for j, b := range []*batchAuth{first, second} {
+			if len(b.batches) != len(tt.batches[j]) {
+				t.Errorf("%s: expected member %d batches %v, got %v", tt.name, j, tt.batches[j], b.batches)
+				continue
+			}
+			for k := range b.batches {
+				if b.batches[k] != tt.batches[j][k] {
+					t.Errorf("%s: expected member %d batches %v, got %v", tt.name, j, tt.batches[j], b.batches)
+				}
+			}
+		}
+	}
+}
+
+func TestAuthenticateBatchFallback(t *testing.T) {
+	rs := newBatch(t, "/a", "/b")
+	auth := AuthenticaterFunc(func(r *http.Request) bool { return r.URL.Path == "/b" })
+
+	got := AuthenticateBatch(auth, rs)
+	if got[0] || !got[1] {
+		t.Errorf("Expected [false true], got %v", got)
+	}
+
+	if got := AuthenticateBatch(And(auth, errAuth{err: errors.New("backend down")}), rs); got[0] || got[1] {
+		t.Errorf("Expected errors not to authenticate, got %v", got)
+	}
+}
@@ -0,0 +1,24 @@
+package authenticater
+
+import (
+	"net/http"
+	"strings"
+)
+
+// BearerToken returns the token from an "Authorization: Bearer <token>"
+// header. The scheme is matched case-insensitively and surrounding whitespace
+// is ignored. ok is false if the header is missing, uses another scheme or
+// has an empty token.
+func BearerToken(r *http.Request) (token string, ok bool) {
+	auth := strings.TrimSpace(r.Header.Get("Authorization"))
+	const prefix = "bearer"
+	if len(auth) <= len(prefix) || !strings.EqualFold(auth[:len(prefix)], prefix) {
+		return "", false
+	}
+	rest := auth[len(prefix):]
+	if rest[0] != ' ' && rest[0] != '\t' {
+		return "", false
+	}
+	token = strings.TrimSpace(rest)
+	return token, token != ""
+}
@@ -0,0 +1,51 @@
+package authenticater
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"sync"
+)
+
+// BearerAuth ensures that the request's "Authorization: Bearer <token>" header
+// contains a known token and is safe for concurrent use.
+type BearerAuth struct {
+	sync.RWMutex
+	tokens [][sha256.Size]byte
+}
+
+// NewBearerAuth returns an empty BearerAuth Authenticator
+func NewBearerAuth() *BearerAuth {
+	return &BearerAuth{}
+}
+
+// AddToken adds a token to the list of acceptable tokens.
+func (ba *BearerAuth) AddToken(token string) {
+	ba.Lock()
+	ba.tokens = append(ba.tokens, sha256.Sum256([]byte(token)))
+	ba.Unlock()
+}
+
+// Authenticate the request if its Bearer token is known. The token is
+// compared against every known token in constant time.
+func (ba *BearerAuth) Authenticate(r *http.Request) bool {
+	token, ok := BearerToken(r)
+	if !ok {
+		return false
+	}
+	sum := sha256.Sum256([]byte(token))
+
+	ba.RLock()
+	defer ba.RUnlock()
+
+	match := 0
+	for _, t := range ba.tokens {
+		match |= subtle.ConstantTimeCompare(t[:], sum[:])
+	}
+	return match == 1
+}
+
+// Challenge returns a Bearer WWW-Authenticate challenge.
+func (ba *BearerAuth) Challenge() string {
+	return "Bearer"
+}
@@ -0,0 +1,72 @@
+package authenticater
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestBearerAuth(t *testing.T) {
+	ba := NewBearerAuth()
+	ba.AddToken("token1")
+	ba.AddToken("token2")
+
+	tests := []struct {
+		header string
+		ok     bool
+	}{
+		{"Bearer token1", true},
+		{"Bearer token2", true},
+		{"Bearer token3", false},
+		{"Bearer token", false},
+		{"Basic dG9rZW4xOg==", false},
+		{"token1", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		w, called := serveWrapped(t, ba, func(r *http.Request) {
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+		})
+		if called != tt.ok {
+			t.Errorf("Authorization %q: expected %t, got %t", tt.header, tt.ok, called)
+		}
+		if !tt.ok && w.Header().Get("WWW-Authenticate") != "Bearer" {
+			t.Errorf("Authorization %q: expected a Bearer challenge, got %q", tt.header, w.Header().Get("WWW-Authenticate"))
+		}
+	}
+}
+
+// run with -race
+func TestBearerAuthRace(t *testing.T) {
+	ba := NewBearerAuth()
+	wg := sync.WaitGroup{}
+	for i := 0; i <= 1000; i++ {
+		wg.Add(1)
+		go func(v int) {
+			ba.AddToken("test" + strconv.Itoa(v))
+			wg.Done()
+		}(i)
+	}
+	wg.Wait()
+	for i := 0; i <= 1000; i++ {
+		wg.Add(1)
+		go func(v int) {
+			if r, err := http.NewRequest("GET", "/", bytes.NewBufferString("")); err != nil {
+				log.Fatalf("Unable to create request #%d\n", v)
+			} else {
+				r.Header.Add("Authorization", "Bearer test"+strconv.Itoa(v))
+				if !ba.Authenticate(r) {
+					log.Fatalf("Unable to authenticate request #%d\n", v)
+				}
+			}
+			wg.Done()
+		}(i)
+	}
+	wg.Wait()
+}
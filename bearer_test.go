@@ -0,0 +1,45 @@
+package authenticater
+
+import (
+	"net/http"
+	"testing"
+)
+
+// newBearerRequest returns a request carrying token as a Bearer token, or no
+// Authorization header if token is empty.
+func newBearerRequest(t *testing.T, token string) *http.Request {
+	r := newTestRequest(t, "GET", "/", "")
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	return r
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		header string
+		token  string
+		ok     bool
+	}{
+		{"Bearer abc123", "abc123", true},
+		{"bearer abc123", "abc123", true},
+		{"BEARER abc123", "abc123", true},
+		{"  Bearer \t abc123  ", "abc123", true},
+		{"Basic Zm9vOmJhcg==", "", false},
+		{"Bearerabc123", "", false},
+		{"Bearer ", "", false},
+		{"Bearer", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		r := newTestRequest(t, "GET", "/", "")
+		if tt.header != "" {
+			r.Header.Set("Authorization", tt.header)
+		}
+		token, ok := BearerToken(r)
+		if token != tt.token || ok != tt.ok {
+			t.Errorf("Authorization %q: expected (%q, %t), got (%q, %t)", tt.header, tt.token, tt.ok, token, ok)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package authenticater
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// DefaultMaxBodySize is the largest request body read to check a signature
+// by default.
+const DefaultMaxBodySize = 1 << 20
+
+// readBody reads up to max bytes of the request body and replaces the body
+// with an identical one so the handler can read it again. A body larger than
+// max is an error, and is left unread beyond what was read to find out.
+func readBody(r *http.Request, max int64) ([]byte, error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, max+1))
+	if int64(len(body)) > max {
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+		return nil, fmt.Errorf("Request body exceeds %d bytes", max)
+	}
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, err
+}
@@ -0,0 +1,122 @@
+package authenticater
+
+import (
+	"net/http"
+	"os"
+)
+
+type and []Authenticater
+
+// And returns an Authenticater that authenticates a request if and only if
+// all of auths do. Evaluation stops at the first that doesn't. And with no
+// Authenticaters authenticates every request.
+func And(auths ...Authenticater) Authenticater {
+	return and(auths)
+}
+
+func (a and) Authenticate(r *http.Request) bool {
+	ok, err := a.AuthenticateErr(r)
+	return ok && err == nil
+}
+
+// AuthenticateErr stops at, and returns, the first error.
+func (a and) AuthenticateErr(r *http.Request) (bool, error) {
+	for _, auth := range a {
+		if ok, err := authenticate(auth, r); !ok || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// AuthenticateBatch checks the batch against each Authenticater in turn,
+// passing on only the requests that have succeeded so far.
+func (a and) AuthenticateBatch(rs []*http.Request) []bool {
+	results := make([]bool, len(rs))
+	for i := range results {
+		results[i] = true
+	}
+	for _, auth := range a {
+		batchWhere(auth, rs, results, func(i int, ok bool) { results[i] = ok })
+	}
+	return results
+}
+
+type or []Authenticater
+
+// Or returns an Authenticater that authenticates a request if any of auths
+// does. Evaluation stops at the first that does. Or with no Authenticaters
+// authenticates no request.
+func Or(auths ...Authenticater) Authenticater {
+	return or(auths)
+}
+
+func (o or) Authenticate(r *http.Request) bool {
+	ok, err := o.AuthenticateErr(r)
+	return ok && err == nil
+}
+
+// AuthenticateErr returns the first error if no Authenticater succeeded.
+func (o or) AuthenticateErr(r *http.Request) (bool, error) {
+	var firstErr error
+	for _, auth := range o {
+		ok, err := authenticate(auth, r)
+		if ok && err == nil {
+			return true, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return false, firstErr
+}
+
+// AuthenticateBatch checks the batch against each Authenticater in turn,
+// passing on only the requests that haven't succeeded yet.
+func (o or) AuthenticateBatch(rs []*http.Request) []bool {
+	results := make([]bool, len(rs))
+	pending := make([]bool, len(rs))
+	for _, auth := range o {
+		for i, ok := range results {
+			pending[i] = !ok
+		}
+		batchWhere(auth, rs, pending, func(i int, ok bool) { results[i] = ok })
+	}
+	return results
+}
+
+type not struct {
+	auth Authenticater
+}
+
+// Not returns an Authenticater that authenticates a request if and only if
+// auth doesn't.
+func Not(auth Authenticater) Authenticater {
+	return not{auth}
+}
+
+func (n not) Authenticate(r *http.Request) bool {
+	ok, err := n.AuthenticateErr(r)
+	return ok && err == nil
+}
+
+// AuthenticateErr doesn't invert errors: if auth fails with an error so does
+// Not.
+func (n not) AuthenticateErr(r *http.Request) (bool, error) {
+	ok, err := authenticate(n.auth, r)
+	if err != nil {
+		return false, err
+	}
+	return !ok, nil
+}
+
+// EnvGated returns permissive if the environment variable name is set to
+// value, and strict otherwise, e.g. EnvGated("ENV", "development",
+// AnyOrNoAuth{}, ba). The environment is read once, when EnvGated is called,
+// and an empty value never selects permissive.
+func EnvGated(name, value string, permissive, strict Authenticater) Authenticater {
+	if v, ok := os.LookupEnv(name); ok && value != "" && v == value {
+		return permissive
+	}
+	return strict
+}
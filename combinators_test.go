@@ -0,0 +1,137 @@
+package authenticater
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+)
+
+// countingAuth returns a fixed result and counts how often it was asked.
+type countingAuth struct {
+	result bool
+	calls  int
+}
+
+func (c *countingAuth) Authenticate(r *http.Request) bool {
+	c.calls++
+	return c.result
+}
+
+func TestCombinators(t *testing.T) {
+	r := newTestRequest(t, "GET", "/", "")
+
+	tests := []struct {
+		name    string
+		results []bool
+		combine func(...Authenticater) Authenticater
+		ok      bool
+		calls   []int
+	}{
+		{"And all true", []bool{true, true}, And, true, []int{1, 1}},
+		{"And stops at false", []bool{true, false, true}, And, false, []int{1, 1, 0}},
+		{"And empty", nil, And, true, nil},
+		{"Or stops at true", []bool{false, true, false}, Or, true, []int{1, 1, 0}},
+		{"Or all false", []bool{false, false}, Or, false, []int{1, 1}},
+		{"Or empty", nil, Or, false, nil},
+	}
+
+	for _, tt := range tests {
+		auths := make([]Authenticater, len(tt.results))
+		counters := make([]*countingAuth, len(tt.results))
+		for i, result := range tt.results {
+			counters[i] = &countingAuth{result: result}
+			auths[i] = counters[i]
+		}
+
+		if got := tt.combine(auths...).Authenticate(r); got != tt.ok {
+			t.Errorf("%s: expected %t, got %t", tt.name, tt.ok, got)
+		}
+		for i, c := range counters {
+			if c.calls != tt.calls[i] {
+				t.Errorf("%s: expected member %d to be called %d times, got %d", tt.name, i, tt.calls[i], c.calls)
+			}
+		}
+	}
+}
+
+func TestNot(t *testing.T) {
+	r := newTestRequest(t, "GET", "/", "")
+
+	if Not(AnyOrNoAuth{}).Authenticate(r) {
+		t.Error("Expected Not(AnyOrNoAuth) to deny")
+	}
+	if !Not(Not(AnyOrNoAuth{})).Authenticate(r) {
+		t.Error("Expected Not(Not(AnyOrNoAuth)) to allow")
+	}
+}
+
+func TestCombinatorsCompose(t *testing.T) {
+	ba, err := NewBasicAuthFromString("foo:bar")
+	if err != nil {
+		t.Fatalf("Unable to construct basic auth checker: %s", err)
+	}
+	internal := AuthenticaterFunc(func(r *http.Request) bool { return r.Header.Get("X-Internal") == "yes" })
+	auth := And(ba, Not(internal))
+
+	if !basicAuthOK(t, auth, "foo", "bar") {
+		t.Error("Expected valid credentials from outside to be accepted")
+	}
+	if basicAuthOK(t, auth, "foo", "baz") {
+		t.Error("Expected invalid credentials to be rejected")
+	}
+}
+
+func TestCombinatorsErrors(t *testing.T) {
+	r := newTestRequest(t, "GET", "/", "")
+	down := errAuth{err: errors.New("backend down")}
+
+	tests := []struct {
+		name string
+		auth Authenticater
+		ok   bool
+		err  bool
+	}{
+		{"And with error", And(AnyOrNoAuth{}, down), false, true},
+		{"And denied before error", And(Not(AnyOrNoAuth{}), down), false, false},
+		{"Or with error", Or(Not(AnyOrNoAuth{}), down), false, true},
+		{"Or allowed despite error", Or(down, AnyOrNoAuth{}), true, false},
+		{"Not with error", Not(down), false, true},
+	}
+
+	for _, tt := range tests {
+		ok, err := authenticate(tt.auth, r)
+		if ok != tt.ok || (err != nil) != tt.err {
+			t.Errorf("%s: expected (%t, error = %t), got (%t, %v)", tt.name, tt.ok, tt.err, ok, err)
+		}
+	}
+}
+
+func TestEnvGated(t *testing.T) {
+	r := newTestRequest(t, "GET", "/", "")
+	strict := Not(AnyOrNoAuth{})
+
+	tests := []struct {
+		name     string
+		env      string
+		set      bool
+		expected string
+		ok       bool
+	}{
+		{"matching", "development", true, "development", true},
+		{"production", "production", true, "development", false},
+		{"unset", "", false, "development", false},
+		{"empty expected value", "", true, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("AUTHENTICATER_TEST_ENV", tt.env)
+		if !tt.set {
+			os.Unsetenv("AUTHENTICATER_TEST_ENV")
+		}
+		auth := EnvGated("AUTHENTICATER_TEST_ENV", tt.expected, AnyOrNoAuth{}, strict)
+		if got := auth.Authenticate(r); got != tt.ok {
+			t.Errorf("%s: expected %t, got %t", tt.name, tt.ok, got)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package authenticater
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Ed25519Auth ensures that a request is signed with the Ed25519 private key
+// matching one of a set of public keys, identified by key ID so keys can be
+// rotated. It is safe for concurrent use.
+//
+// The key ID is read from KeyIDHeader and the base64 (standard encoding)
+// signature from SignatureHeader. The signed message is
+//
+//	METHOD + "\n" + request URI (path and query) + "\n" + body
+//
+// The request body is restored so the handler can still read it.
+type Ed25519Auth struct {
+	sync.RWMutex
+	keys map[string]ed25519.PublicKey
+
+	KeyIDHeader     string
+	SignatureHeader string
+
+	// MaxBodySize is the largest body read to check the signature. Requests
+	// with larger bodies are denied.
+	MaxBodySize int64
+}
+
+// NewEd25519Auth returns an Ed25519Auth with no keys, reading the key ID from
+// X-Key-Id and the signature from X-Signature, and reading bodies of up to
+// DefaultMaxBodySize.
+func NewEd25519Auth() *Ed25519Auth {
+	return &Ed25519Auth{
+		keys:            make(map[string]ed25519.PublicKey),
+		KeyIDHeader:     "X-Key-Id",
+		SignatureHeader: "X-Signature",
+		MaxBodySize:     DefaultMaxBodySize,
+	}
+}
+
+// AddKey adds, or replaces, the public key with the given ID.
+func (ea *Ed25519Auth) AddKey(id string, key ed25519.PublicKey) error {
+	if len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("Invalid Ed25519 public key length for key ID '%s': %d", id, len(key))
+	}
+	ea.Lock()
+	ea.keys[id] = key
+	ea.Unlock()
+	return nil
+}
+
+// RemoveKey retires the public key with the given ID.
+func (ea *Ed25519Auth) RemoveKey(id string) {
+	ea.Lock()
+	delete(ea.keys, id)
+	ea.Unlock()
+}
+
+// Authenticate the request if it is signed by the key its key ID refers to.
+func (ea *Ed25519Auth) Authenticate(r *http.Request) bool {
+	ea.RLock()
+	key, exists := ea.keys[r.Header.Get(ea.KeyIDHeader)]
+	ea.RUnlock()
+	if !exists {
+		return false
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(r.Header.Get(ea.SignatureHeader))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return false
+	}
+
+	body, err := readBody(r, ea.MaxBodySize)
+	if err != nil {
+		return false
+	}
+
+	msg := []byte(r.Method + "\n" + r.URL.RequestURI() + "\n")
+	return ed25519.Verify(key, append(msg, body...), sig)
+}
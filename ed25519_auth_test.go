@@ -0,0 +1,95 @@
+package authenticater
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func newEd25519Request(t *testing.T, keyID string, key ed25519.PrivateKey, body string) *http.Request {
+	r := newTestRequest(t, "POST", "/things?limit=1", body)
+	sig := ed25519.Sign(key, []byte("POST\n/things?limit=1\n"+body))
+	r.Header.Set("X-Key-Id", keyID)
+	r.Header.Set("X-Signature", base64.StdEncoding.EncodeToString(sig))
+	return r
+}
+
+func newEd25519Key(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("Unable to generate key: %s", err)
+	}
+	return pub, priv
+}
+
+func TestEd25519Auth(t *testing.T) {
+	oldPub, oldPriv := newEd25519Key(t)
+	newPub, newPriv := newEd25519Key(t)
+	_, otherPriv := newEd25519Key(t)
+
+	ea := NewEd25519Auth()
+	if err := ea.AddKey("2023", oldPub); err != nil {
+		t.Fatalf("Unable to add key: %s", err)
+	}
+	if err := ea.AddKey("2024", newPub); err != nil {
+		t.Fatalf("Unable to add key: %s", err)
+	}
+
+	const body = `{"name":"thing"}`
+	r := newEd25519Request(t, "2024", newPriv, body)
+	if !ea.Authenticate(r) {
+		t.Error("Expected a valid signature to be accepted")
+	}
+	if b, _ := io.ReadAll(r.Body); string(b) != body {
+		t.Errorf("Expected body %q to be restored, got %q", body, b)
+	}
+
+	if !ea.Authenticate(newEd25519Request(t, "2023", oldPriv, body)) {
+		t.Error("Expected a signature by the older active key to be accepted")
+	}
+	if ea.Authenticate(newEd25519Request(t, "2025", newPriv, body)) {
+		t.Error("Expected an unknown key ID to be rejected")
+	}
+	if ea.Authenticate(newEd25519Request(t, "2024", otherPriv, body)) {
+		t.Error("Expected a bad signature to be rejected")
+	}
+	if ea.Authenticate(newEd25519Request(t, "2023", newPriv, body)) {
+		t.Error("Expected a signature by a different key ID's key to be rejected")
+	}
+
+	tampered := newEd25519Request(t, "2024", newPriv, body)
+	tampered.Body = io.NopCloser(bytes.NewBufferString(`{"name":"other"}`))
+	if ea.Authenticate(tampered) {
+		t.Error("Expected a tampered body to be rejected")
+	}
+
+	ea.RemoveKey("2023")
+	if ea.Authenticate(newEd25519Request(t, "2023", oldPriv, body)) {
+		t.Error("Expected a retired key to be rejected")
+	}
+}
+
+func TestEd25519AuthBadKey(t *testing.T) {
+	if err := NewEd25519Auth().AddKey("short", ed25519.PublicKey("short")); err == nil {
+		t.Error("Expected an error for an invalid public key")
+	}
+}
+
+func TestEd25519AuthMaxBodySize(t *testing.T) {
+	pub, priv := newEd25519Key(t)
+	ea := NewEd25519Auth()
+	if err := ea.AddKey("2024", pub); err != nil {
+		t.Fatalf("Unable to add key: %s", err)
+	}
+	ea.MaxBodySize = 4
+
+	if ea.Authenticate(newEd25519Request(t, "2024", priv, "12345")) {
+		t.Error("Expected a body over MaxBodySize to be denied")
+	}
+	if !ea.Authenticate(newEd25519Request(t, "2024", priv, "1234")) {
+		t.Error("Expected a body within MaxBodySize to be accepted")
+	}
+}
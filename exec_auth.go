@@ -0,0 +1,70 @@
+//go:build !windows
+
+package authenticater
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// DefaultExecAuthTimeout is how long an ExecAuth command may run before it is
+// killed and the request denied.
+const DefaultExecAuthTimeout = 5 * time.Second
+
+// ExecAuth delegates verification of Basic Auth credentials to an external
+// command, checkpassword style. The command is run directly (never through a
+// shell) with only Env in its environment, and receives the username and
+// password on stdin as "user\x00password\x00". An exit status of 0 means the
+// credentials are valid; any other status, a failure to start, or running
+// past Timeout denies the request.
+type ExecAuth struct {
+	// Path of the command to run and any fixed arguments to pass it.
+	// Credentials are never passed as arguments.
+	Path string
+	Args []string
+
+	// Env is the complete environment of the command. It defaults to empty
+	// rather than inheriting the environment of this process.
+	Env []string
+
+	// Timeout bounds how long the command may run.
+	Timeout time.Duration
+}
+
+// NewExecAuth returns an ExecAuth running the command at path with args and
+// DefaultExecAuthTimeout.
+func NewExecAuth(path string, args ...string) *ExecAuth {
+	return &ExecAuth{
+		Path:    path,
+		Args:    args,
+		Timeout: DefaultExecAuthTimeout,
+	}
+}
+
+// Authenticate is true if the request has Basic Auth credentials and the
+// command exits successfully when given them.
+func (ea *ExecAuth) Authenticate(r *http.Request) bool {
+	user, pass, ok := r.BasicAuth()
+	if !ok || user == "" || strings.ContainsRune(user, 0) || strings.ContainsRune(pass, 0) {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), ea.Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, ea.Path, ea.Args...)
+	cmd.Env = ea.Env
+	if cmd.Env == nil {
+		cmd.Env = []string{}
+	}
+	cmd.Stdin = strings.NewReader(user + "\x00" + pass + "\x00")
+	cmd.Stdout = &bytes.Buffer{}
+	cmd.Stderr = &bytes.Buffer{}
+	cmd.WaitDelay = time.Second
+
+	return cmd.Run() == nil
+}
@@ -0,0 +1,63 @@
+//go:build !windows
+
+package authenticater
+
+import (
+	"testing"
+	"time"
+)
+
+// checkScript succeeds only for foo/bar, reading the NUL separated
+// credentials from stdin.
+const checkScript = `test "$(tr '\000' ' ')" = "foo bar "`
+
+func TestExecAuth(t *testing.T) {
+	tests := []struct {
+		name       string
+		ea         *ExecAuth
+		user, pass string
+		ok         bool
+	}{
+		{"success", NewExecAuth("/bin/sh", "-c", checkScript), "foo", "bar", true},
+		{"wrong password", NewExecAuth("/bin/sh", "-c", checkScript), "foo", "baz", false},
+		{"failing command", NewExecAuth("/bin/sh", "-c", "exit 1"), "foo", "bar", false},
+		{"missing command", NewExecAuth("/nonexistent/checkpassword"), "foo", "bar", false},
+		{"injection attempt", NewExecAuth("/bin/sh", "-c", checkScript), "foo bar; true", "", false},
+	}
+
+	for _, tt := range tests {
+		r := newTestRequest(t, "GET", "/", "")
+		r.SetBasicAuth(tt.user, tt.pass)
+		if got := tt.ea.Authenticate(r); got != tt.ok {
+			t.Errorf("%s: expected %t, got %t", tt.name, tt.ok, got)
+		}
+	}
+}
+
+func TestExecAuthEnvironmentScrubbed(t *testing.T) {
+	t.Setenv("EXEC_AUTH_SECRET", "leaked")
+	ea := NewExecAuth("/bin/sh", "-c", `test -z "$EXEC_AUTH_SECRET" && test "$ONLY" = set`)
+	ea.Env = []string{"ONLY=set"}
+
+	r := newTestRequest(t, "GET", "/", "")
+	r.SetBasicAuth("foo", "bar")
+	if !ea.Authenticate(r) {
+		t.Error("Expected the command to see only the configured environment")
+	}
+}
+
+func TestExecAuthTimeout(t *testing.T) {
+	ea := NewExecAuth("/bin/sh", "-c", "exec sleep 10")
+	ea.Timeout = 50 * time.Millisecond
+
+	r := newTestRequest(t, "GET", "/", "")
+	r.SetBasicAuth("foo", "bar")
+
+	start := time.Now()
+	if ea.Authenticate(r) {
+		t.Error("Expected a hung command to be denied")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the command to be killed after the timeout, took %s", elapsed)
+	}
+}
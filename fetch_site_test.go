@@ -0,0 +1,45 @@
+package authenticater
+
+import "testing"
+
+func TestFetchSite(t *testing.T) {
+	fs := NewFetchSite()
+
+	tests := []struct {
+		method, site string
+		ok           bool
+	}{
+		{"POST", "cross-site", false},
+		{"DELETE", "Cross-Site", false},
+		{"POST", "same-origin", true},
+		{"PUT", "same-site", true},
+		{"PATCH", "none", true},
+		{"POST", "", true},
+		{"GET", "cross-site", true},
+	}
+
+	for _, tt := range tests {
+		r := newTestRequest(t, tt.method, "/settings", "")
+		if tt.site != "" {
+			r.Header.Set("Sec-Fetch-Site", tt.site)
+		}
+		if got := fs.Authenticate(r); got != tt.ok {
+			t.Errorf("%s with Sec-Fetch-Site %q: expected %t, got %t", tt.method, tt.site, tt.ok, got)
+		}
+	}
+}
+
+func TestFetchSiteMethods(t *testing.T) {
+	fs := NewFetchSite("get")
+
+	r := newTestRequest(t, "GET", "/settings", "")
+	r.Header.Set("Sec-Fetch-Site", "cross-site")
+	if fs.Authenticate(r) {
+		t.Error("Expected cross-site GET to be denied")
+	}
+
+	r.Method = "POST"
+	if !fs.Authenticate(r) {
+		t.Error("Expected cross-site POST to be allowed when only GET is checked")
+	}
+}
@@ -0,0 +1,5 @@
+module github.com/heroku/authenticater
+
+go 1.20
+
+require golang.org/x/crypto v0.17.0
@@ -0,0 +1,212 @@
+package authenticater
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultHerokuAPIURL is the Heroku Platform API used by HerokuToken.
+	DefaultHerokuAPIURL = "https://api.heroku.com"
+
+	// DefaultHerokuTokenTTL is how long HerokuToken caches the outcome of
+	// checking a token.
+	DefaultHerokuTokenTTL = time.Minute
+
+	// DefaultHerokuTokenCacheSize is how many tokens HerokuToken caches the
+	// outcome of checking.
+	DefaultHerokuTokenCacheSize = 10000
+)
+
+// HerokuToken ensures that the request carries a Heroku OAuth token as a
+// Bearer token that the Heroku Platform API accepts, optionally belonging to
+// one of a set of accounts or teams. Outcomes are cached per token for TTL;
+// API and network errors deny the request and are not cached. It is safe for
+// concurrent use.
+type HerokuToken struct {
+	sync.Mutex
+
+	// APIURL is the base URL of the Heroku Platform API.
+	APIURL string
+
+	// Client is used to call the Heroku Platform API.
+	Client *http.Client
+
+	// TTL is how long a token's outcome is cached.
+	TTL time.Duration
+
+	// CacheSize caps the number of cached outcomes. When the cache is full
+	// expired entries are dropped, and then an arbitrary entry if it is still
+	// full. Zero disables caching.
+	CacheSize int
+
+	emails map[string]struct{}
+	teams  map[string]struct{}
+	cache  map[[sha256.Size]byte]herokuTokenResult
+	now    func() time.Time
+}
+
+type herokuTokenResult struct {
+	ok      bool
+	expires time.Time
+}
+
+// NewHerokuToken returns a HerokuToken accepting any valid Heroku token.
+func NewHerokuToken() *HerokuToken {
+	return &HerokuToken{
+		APIURL:    DefaultHerokuAPIURL,
+		Client:    &http.Client{Timeout: 10 * time.Second},
+		TTL:       DefaultHerokuTokenTTL,
+		CacheSize: DefaultHerokuTokenCacheSize,
+		emails:    make(map[string]struct{}),
+		teams:     make(map[string]struct{}),
+		cache:     make(map[[sha256.Size]byte]herokuTokenResult),
+		now:       time.Now,
+	}
+}
+
+// AddEmail restricts tokens to those of accounts with one of the added
+// emails.
+func (ht *HerokuToken) AddEmail(email string) {
+	ht.Lock()
+	ht.emails[strings.ToLower(email)] = struct{}{}
+	ht.Unlock()
+}
+
+// AddTeam restricts tokens to those of accounts that are members of one of
+// the added teams.
+func (ht *HerokuToken) AddTeam(team string) {
+	ht.Lock()
+	ht.teams[team] = struct{}{}
+	ht.Unlock()
+}
+
+// Authenticate the request if its Bearer token is accepted by the Heroku API
+// and matches the configured emails and teams.
+func (ht *HerokuToken) Authenticate(r *http.Request) bool {
+	ok, err := ht.AuthenticateErr(r)
+	return ok && err == nil
+}
+
+// AuthenticateErr is like Authenticate but returns an error if the Heroku API
+// couldn't be queried.
+func (ht *HerokuToken) AuthenticateErr(r *http.Request) (bool, error) {
+	token, ok := BearerToken(r)
+	if !ok {
+		return false, nil
+	}
+
+	key := sha256.Sum256([]byte(token))
+	now := ht.now()
+
+	ht.Lock()
+	res, cached := ht.cache[key]
+	ht.Unlock()
+	if cached && now.Before(res.expires) {
+		return res.ok, nil
+	}
+
+	ok, err := ht.check(token)
+	if err != nil {
+		return false, err
+	}
+
+	ht.Lock()
+	ht.store(key, herokuTokenResult{ok: ok, expires: now.Add(ht.TTL)}, now)
+	ht.Unlock()
+	return ok, nil
+}
+
+// store caches res under key, making room if the cache is full. The caller
+// must hold the lock.
+func (ht *HerokuToken) store(key [sha256.Size]byte, res herokuTokenResult, now time.Time) {
+	if ht.CacheSize <= 0 {
+		return
+	}
+	if _, exists := ht.cache[key]; !exists && len(ht.cache) >= ht.CacheSize {
+		for k, r := range ht.cache {
+			if !now.Before(r.expires) {
+				delete(ht.cache, k)
+			}
+		}
+		for k := range ht.cache {
+			if len(ht.cache) < ht.CacheSize {
+				break
+			}
+			delete(ht.cache, k)
+		}
+	}
+	ht.cache[key] = res
+}
+
+// check asks the Heroku API about token. A token the API rejects is reported
+// as not ok rather than as an error.
+func (ht *HerokuToken) check(token string) (bool, error) {
+	var account struct {
+		Email string `json:"email"`
+	}
+	if ok, err := ht.get("/account", token, &account); !ok || err != nil {
+		return ok, err
+	}
+
+	ht.Lock()
+	_, emailOK := ht.emails[strings.ToLower(account.Email)]
+	emailOK = emailOK || len(ht.emails) == 0
+	needTeams := len(ht.teams) > 0
+	ht.Unlock()
+
+	if !emailOK {
+		return false, nil
+	}
+	if !needTeams {
+		return true, nil
+	}
+
+	var teams []struct {
+		Name string `json:"name"`
+	}
+	if ok, err := ht.get("/teams", token, &teams); !ok || err != nil {
+		return ok, err
+	}
+
+	ht.Lock()
+	defer ht.Unlock()
+	for _, team := range teams {
+		if _, exists := ht.teams[team.Name]; exists {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (ht *HerokuToken) get(path, token string, v interface{}) (bool, error) {
+	req, err := http.NewRequest("GET", strings.TrimSuffix(ht.APIURL, "/")+path, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "application/vnd.heroku+json; version=3")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := ht.Client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return false, nil
+	case resp.StatusCode != http.StatusOK:
+		return false, fmt.Errorf("Unexpected status from Heroku API %s: %d", path, resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return false, err
+	}
+	return true, nil
+}
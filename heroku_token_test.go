@@ -0,0 +1,173 @@
+package authenticater
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeHerokuAPI serves /account and /teams for the "good" and "other" tokens
+// and counts the requests it receives.
+type fakeHerokuAPI struct {
+	sync.Mutex
+	requests int
+}
+
+func (f *fakeHerokuAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	f.requests++
+	f.Unlock()
+
+	var email, teams string
+	switch r.Header.Get("Authorization") {
+	case "Bearer good":
+		email, teams = "user@example.com", `[{"name":"ops"}]`
+	case "Bearer other":
+		email, teams = "other@example.com", `[]`
+	case "Bearer broken":
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	default:
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"id":"unauthorized"}`))
+		return
+	}
+
+	switch r.URL.Path {
+	case "/account":
+		w.Write([]byte(`{"email":"` + email + `"}`))
+	case "/teams":
+		w.Write([]byte(teams))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeHerokuAPI) requestCount() int {
+	f.Lock()
+	defer f.Unlock()
+	return f.requests
+}
+
+func TestHerokuToken(t *testing.T) {
+	api := httptest.NewServer(&fakeHerokuAPI{})
+	defer api.Close()
+
+	ht := NewHerokuToken()
+	ht.APIURL = api.URL
+
+	tests := []struct {
+		token string
+		ok    bool
+	}{
+		{"good", true},
+		{"other", true},
+		{"invalid", false},
+		{"broken", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := ht.Authenticate(newBearerRequest(t, tt.token)); got != tt.ok {
+			t.Errorf("token %q: expected %t, got %t", tt.token, tt.ok, got)
+		}
+	}
+}
+
+func TestHerokuTokenEmailAndTeam(t *testing.T) {
+	api := httptest.NewServer(&fakeHerokuAPI{})
+	defer api.Close()
+
+	byEmail := NewHerokuToken()
+	byEmail.APIURL = api.URL
+	byEmail.AddEmail("User@Example.com")
+
+	byTeam := NewHerokuToken()
+	byTeam.APIURL = api.URL
+	byTeam.AddTeam("ops")
+
+	for _, ht := range []*HerokuToken{byEmail, byTeam} {
+		if !ht.Authenticate(newBearerRequest(t, "good")) {
+			t.Error("Expected matching account to be allowed")
+		}
+		if ht.Authenticate(newBearerRequest(t, "other")) {
+			t.Error("Expected non matching account to be denied")
+		}
+	}
+}
+
+func TestHerokuTokenCache(t *testing.T) {
+	fake := &fakeHerokuAPI{}
+	api := httptest.NewServer(fake)
+	defer api.Close()
+
+	now := time.Unix(1500000000, 0)
+	ht := NewHerokuToken()
+	ht.APIURL = api.URL
+	ht.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		ht.Authenticate(newBearerRequest(t, "good"))
+		ht.Authenticate(newBearerRequest(t, "invalid"))
+	}
+	if n := fake.requestCount(); n != 2 {
+		t.Errorf("Expected outcomes to be cached, got %d API requests", n)
+	}
+
+	now = now.Add(DefaultHerokuTokenTTL)
+	ht.Authenticate(newBearerRequest(t, "good"))
+	if n := fake.requestCount(); n != 3 {
+		t.Errorf("Expected the cache entry to expire, got %d API requests", n)
+	}
+
+	ht.Authenticate(newBearerRequest(t, "broken"))
+	ht.Authenticate(newBearerRequest(t, "broken"))
+	if n := fake.requestCount(); n != 5 {
+		t.Errorf("Expected API errors not to be cached, got %d API requests", n)
+	}
+}
+
+func TestHerokuTokenAPIError(t *testing.T) {
+	api := httptest.NewServer(&fakeHerokuAPI{})
+	defer api.Close()
+
+	ht := NewHerokuToken()
+	ht.APIURL = api.URL
+
+	if _, err := ht.AuthenticateErr(newBearerRequest(t, "broken")); err == nil {
+		t.Error("Expected an error when the Heroku API fails")
+	}
+	if ok, err := ht.AuthenticateErr(newBearerRequest(t, "invalid")); ok || err != nil {
+		t.Errorf("Expected an invalid token to be denied without error, got (%t, %v)", ok, err)
+	}
+}
+
+func TestHerokuTokenCacheSize(t *testing.T) {
+	api := httptest.NewServer(&fakeHerokuAPI{})
+	defer api.Close()
+
+	now := time.Unix(1500000000, 0)
+	ht := NewHerokuToken()
+	ht.APIURL = api.URL
+	ht.now = func() time.Time { return now }
+	ht.CacheSize = 3
+
+	for i := 0; i < 3; i++ {
+		ht.Authenticate(newBearerRequest(t, "random"+strconv.Itoa(i)))
+	}
+	now = now.Add(DefaultHerokuTokenTTL)
+	ht.Authenticate(newBearerRequest(t, "good"))
+	if n := len(ht.cache); n != 1 {
+		t.Errorf("Expected expired entries to be swept when full, got %d entries", n)
+	}
+
+	for i := 0; i < 20; i++ {
+		ht.Authenticate(newBearerRequest(t, "attacker"+strconv.Itoa(i)))
+	}
+	if n := len(ht.cache); n != ht.CacheSize {
+		t.Errorf("Expected the cache to be capped at %d entries, got %d", ht.CacheSize, n)
+	}
+}
@@ -0,0 +1,107 @@
+package authenticater
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"testing"
+)
+
+const hmacTestBody = `{"event":"ping"}`
+
+func hmacSign(secret, canonical string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(canonical))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func newHMACRequest(t *testing.T) *http.Request {
+	r := newTestRequest(t, "POST", "/hook", hmacTestBody)
+	r.Header.Set("Date", "Mon, 02 Jan 2006 15:04:05 GMT")
+	r.Header.Set("Content-Type", "application/json")
+	return r
+}
+
+func TestHMACAuthSignedHeaders(t *testing.T) {
+	ha := NewHMACAuth("secret", "X-Signature", "Date", "Content-Type")
+	canonical := "date:Mon, 02 Jan 2006 15:04:05 GMT\ncontent-type:application/json\n" + hmacTestBody
+
+	r := newHMACRequest(t)
+	r.Header.Set("X-Signature", hmacSign("secret", canonical))
+	if !ha.Authenticate(r) {
+		t.Error("Expected a valid signature to be accepted")
+	}
+	if body, _ := io.ReadAll(r.Body); string(body) != hmacTestBody {
+		t.Errorf("Expected body %q to be restored, got %q", hmacTestBody, body)
+	}
+
+	r = newHMACRequest(t)
+	r.Header.Set("X-Signature", hmacSign("secret", canonical))
+	r.Header.Set("Content-Type", "text/plain")
+	if ha.Authenticate(r) {
+		t.Error("Expected a tampered signed header to be rejected")
+	}
+
+	r = newHMACRequest(t)
+	r.Header.Set("X-Signature", hmacSign("secret", canonical))
+	r.Header.Del("Date")
+	if ha.Authenticate(r) {
+		t.Error("Expected a missing signed header to be rejected")
+	}
+
+	r = newHMACRequest(t)
+	r.Header.Set("X-Signature", hmacSign("wrong", canonical))
+	if ha.Authenticate(r) {
+		t.Error("Expected a signature made with the wrong secret to be rejected")
+	}
+
+	r = newHMACRequest(t)
+	if ha.Authenticate(r) {
+		t.Error("Expected a missing signature to be rejected")
+	}
+}
+
+func TestHMACAuthBodyWithPrefix(t *testing.T) {
+	ha := NewHMACAuth("secret", "X-Hub-Signature-256")
+	ha.Prefix = "sha256="
+	sig := hmacSign("secret", hmacTestBody)
+
+	tests := []struct {
+		header string
+		ok     bool
+	}{
+		{"sha256=" + sig, true},
+		{sig, false},
+		{"sha1=" + sig, false},
+		{"sha256=" + hmacSign("wrong", hmacTestBody), false},
+		{"sha256=", false},
+	}
+
+	for _, tt := range tests {
+		r := newHMACRequest(t)
+		r.Header.Set("X-Hub-Signature-256", tt.header)
+		if got := ha.Authenticate(r); got != tt.ok {
+			t.Errorf("signature %q: expected %t, got %t", tt.header, tt.ok, got)
+		}
+	}
+
+	r := newHMACRequest(t)
+	r.Header.Set("X-Hub-Signature-256", "sha256="+sig)
+	ha.Authenticate(r)
+	if body, _ := io.ReadAll(r.Body); string(body) != hmacTestBody {
+		t.Errorf("Expected body %q to be restored, got %q", hmacTestBody, body)
+	}
+}
+
+func TestHMACAuthMaxBodySize(t *testing.T) {
+	ha := NewHMACAuth("secret", "X-Signature")
+	ha.MaxBodySize = int64(len(hmacTestBody)) - 1
+
+	r := newHMACRequest(t)
+	r.Header.Set("X-Signature", hmacSign("secret", hmacTestBody))
+	if ha.Authenticate(r) {
+		t.Error("Expected a body over MaxBodySize to be denied")
+	}
+}
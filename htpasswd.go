@@ -0,0 +1,223 @@
+package authenticater
+
+import (
+	"bufio"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// dummyHash is compared against when a user has no hash and no htpasswd file
+// has been loaded to take a costlier one from. It is a bcrypt.DefaultCost
+// hash, precomputed so importing the package doesn't pay for generating one.
+var dummyHash = []byte("$2a$10$J0nogIlN3kuLJli9V86eLO5S1Tow4WtqdDOjQYVpjHBZLjAEtmtlK")
+
+// NewBasicAuthFromFile creates and populates a BasicAuth from an Apache style
+// htpasswd file, with one user:hash entry per line. Blank lines and lines
+// starting with # are skipped. bcrypt ($2y$), APR1/MD5-crypt ($apr1$) and
+// SHA-1 ({SHA}) hashes are supported; any other entry is an error.
+func NewBasicAuthFromFile(path string) (*BasicAuth, error) {
+	ba := NewBasicAuth()
+	ba.path = path
+	if err := ba.Reload(); err != nil {
+		return nil, err
+	}
+	return ba, nil
+}
+
+// Reload re-reads the htpasswd file a BasicAuth was created from by
+// NewBasicAuthFromFile, replacing all of its principals, including any added
+// with AddPrincipal. If the file can't be read or parsed the current
+// principals are kept.
+func (ba *BasicAuth) Reload() error {
+	if ba.path == "" {
+		return errors.New("BasicAuth was not loaded from a file")
+	}
+
+	creds, err := readHtpasswd(ba.path)
+	if err != nil {
+		return err
+	}
+
+	ba.Lock()
+	ba.creds = creds
+	ba.hashed = len(creds)
+	ba.dummy = slowestHash(creds)
+	ba.Unlock()
+	return nil
+}
+
+func readHtpasswd(path string) (map[[sha256.Size]byte]*principal, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	creds := make(map[[sha256.Size]byte]*principal)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+			return nil, fmt.Errorf("Unable to parse %s line %d: expected user:hash", path, n)
+		}
+		if !supportedHash(parts[1]) {
+			return nil, fmt.Errorf("Unable to parse %s line %d: unsupported hash for user '%s'", path, n, parts[0])
+		}
+
+		key := sha256.Sum256([]byte(parts[0]))
+		p, exists := creds[key]
+		if !exists {
+			p = &principal{}
+			creds[key] = p
+		}
+		p.hashes = append(p.hashes, parts[1])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return creds, nil
+}
+
+// slowestHash returns the loaded hash that is most expensive to verify, so
+// that unknown users can be checked against a hash as slow as any real one
+// rather than against a dummyHash of a fixed cost.
+func slowestHash(creds map[[sha256.Size]byte]*principal) string {
+	slowest, slowestCost := "", -1
+	for _, p := range creds {
+		for _, hash := range p.hashes {
+			if cost := hashCost(hash); cost > slowestCost {
+				slowest, slowestCost = hash, cost
+			}
+		}
+	}
+	return slowest
+}
+
+// hashCost ranks htpasswd hashes by how expensive they are to verify: SHA-1
+// below APR1, and APR1 below bcrypt at any cost.
+func hashCost(hash string) int {
+	switch {
+	case strings.HasPrefix(hash, "$2"):
+		cost, _ := bcrypt.Cost([]byte(hash))
+		return 2 + cost
+	case strings.HasPrefix(hash, "$apr1$"):
+		return 1
+	}
+	return 0
+}
+
+func supportedHash(hash string) bool {
+	switch {
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		_, err := bcrypt.Cost([]byte(hash))
+		return err == nil
+	case strings.HasPrefix(hash, "$apr1$"):
+		return strings.Count(hash, "$") == 3
+	case strings.HasPrefix(hash, "{SHA}"):
+		return true
+	}
+	return false
+}
+
+// verifyHash reports whether pass matches an htpasswd hash.
+func verifyHash(hash, pass string) bool {
+	var computed string
+	switch {
+	case strings.HasPrefix(hash, "$2"):
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(pass)) == nil
+	case strings.HasPrefix(hash, "$apr1$"):
+		salt := strings.SplitN(hash[len("$apr1$"):], "$", 2)[0]
+		computed = apr1Crypt(pass, salt)
+	case strings.HasPrefix(hash, "{SHA}"):
+		sum := sha1.Sum([]byte(pass))
+		computed = "{SHA}" + base64.StdEncoding.EncodeToString(sum[:])
+	default:
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(hash)) == 1
+}
+
+// apr1Crypt implements Apache's variant of the MD5-crypt password hash.
+func apr1Crypt(pass, salt string) string {
+	const magic = "$apr1$"
+	if len(salt) > 8 {
+		salt = salt[:8]
+	}
+	pw, s := []byte(pass), []byte(salt)
+
+	alt := md5.New()
+	alt.Write(pw)
+	alt.Write(s)
+	alt.Write(pw)
+	sum := alt.Sum(nil)
+
+	ctx := md5.New()
+	ctx.Write(pw)
+	ctx.Write([]byte(magic))
+	ctx.Write(s)
+	for i := len(pw); i > 0; i -= 16 {
+		if i > 16 {
+			ctx.Write(sum)
+		} else {
+			ctx.Write(sum[:i])
+		}
+	}
+	for i := len(pw); i > 0; i >>= 1 {
+		if i&1 == 1 {
+			ctx.Write([]byte{0})
+		} else {
+			ctx.Write(pw[:1])
+		}
+	}
+	sum = ctx.Sum(nil)
+
+	for i := 0; i < 1000; i++ {
+		round := md5.New()
+		if i&1 == 1 {
+			round.Write(pw)
+		} else {
+			round.Write(sum)
+		}
+		if i%3 != 0 {
+			round.Write(s)
+		}
+		if i%7 != 0 {
+			round.Write(pw)
+		}
+		if i&1 == 1 {
+			round.Write(sum)
+		} else {
+			round.Write(pw)
+		}
+		sum = round.Sum(nil)
+	}
+
+	const itoa64 = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	out := make([]byte, 0, 22)
+	encode := func(v uint32, n int) {
+		for ; n > 0; n-- {
+			out = append(out, itoa64[v&0x3f])
+			v >>= 6
+		}
+	}
+	for _, i := range [][3]int{{0, 6, 12}, {1, 7, 13}, {2, 8, 14}, {3, 9, 15}, {4, 10, 5}} {
+		encode(uint32(sum[i[0]])<<16|uint32(sum[i[1]])<<8|uint32(sum[i[2]]), 4)
+	}
+	encode(uint32(sum[11]), 2)
+
+	return magic + salt + "$" + string(out)
+}
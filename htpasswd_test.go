@@ -0,0 +1,172 @@
+package authenticater
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func writeHtpasswd(t *testing.T, lines ...string) string {
+	path := filepath.Join(t.TempDir(), "htpasswd")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatalf("Unable to write htpasswd file: %s", err)
+	}
+	return path
+}
+
+func TestAPR1Crypt(t *testing.T) {
+	// Generated with: openssl passwd -apr1 -salt <salt> <password>
+	tests := []struct {
+		pass, salt, hash string
+	}{
+		{"password", "saltsalt", "$apr1$saltsalt$yAAkm4libquA.ZWLHbSBq/"},
+		{"p@ss:word", "ab", "$apr1$ab$QzyNjRREJLGN2uVg93xVf1"},
+	}
+
+	for _, tt := range tests {
+		if got := apr1Crypt(tt.pass, tt.salt); got != tt.hash {
+			t.Errorf("apr1Crypt(%q, %q): expected %s, got %s", tt.pass, tt.salt, tt.hash, got)
+		}
+	}
+}
+
+func TestDummyHash(t *testing.T) {
+	cost, err := bcrypt.Cost(dummyHash)
+	if err != nil || cost != bcrypt.DefaultCost {
+		t.Errorf("Expected a bcrypt.DefaultCost hash, got cost %d (%v)", cost, err)
+	}
+	if err := bcrypt.CompareHashAndPassword(dummyHash, []byte("dummy")); err != nil {
+		t.Errorf("Expected dummyHash to be a valid hash: %s", err)
+	}
+}
+
+func TestBasicAuthFromFileDummyCost(t *testing.T) {
+	cheap, err := bcrypt.GenerateFromPassword([]byte("pass"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("Unable to hash password: %s", err)
+	}
+	slow, err := bcrypt.GenerateFromPassword([]byte("pass"), bcrypt.MinCost+1)
+	if err != nil {
+		t.Fatalf("Unable to hash password: %s", err)
+	}
+
+	path := writeHtpasswd(t,
+		"alice:$apr1$saltsalt$yAAkm4libquA.ZWLHbSBq/",
+		"bob:"+string(cheap),
+		"carol:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=",
+		"dave:"+string(slow),
+	)
+	ba, err := NewBasicAuthFromFile(path)
+	if err != nil {
+		t.Fatalf("Unable to load htpasswd file: %s", err)
+	}
+	if ba.dummy != string(slow) {
+		t.Errorf("Expected unknown users to be checked against the costliest hash %s, got %s", slow, ba.dummy)
+	}
+
+	path = writeHtpasswd(t, "alice:$apr1$saltsalt$yAAkm4libquA.ZWLHbSBq/", "carol:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=")
+	if ba, err = NewBasicAuthFromFile(path); err != nil {
+		t.Fatalf("Unable to load htpasswd file: %s", err)
+	}
+	if ba.dummy != "$apr1$saltsalt$yAAkm4libquA.ZWLHbSBq/" {
+		t.Errorf("Expected unknown users to be checked against the APR1 hash, got %s", ba.dummy)
+	}
+}
+
+func TestNewBasicAuthFromFile(t *testing.T) {
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("bcryptpass"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("Unable to hash password: %s", err)
+	}
+
+	path := writeHtpasswd(t,
+		"# managed by ops",
+		"",
+		"bob:"+string(bcryptHash),
+		"alice:$apr1$saltsalt$yAAkm4libquA.ZWLHbSBq/",
+		"carol:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=",
+	)
+
+	ba, err := NewBasicAuthFromFile(path)
+	if err != nil {
+		t.Fatalf("Unable to load htpasswd file: %s", err)
+	}
+
+	tests := []struct {
+		user, pass string
+		ok         bool
+	}{
+		{"bob", "bcryptpass", true},
+		{"alice", "password", true},
+		{"carol", "password", true},
+		{"bob", "password", false},
+		{"alice", "bcryptpass", false},
+		{"dave", "password", false},
+	}
+
+	for _, tt := range tests {
+		if got := basicAuthOK(t, ba, tt.user, tt.pass); got != tt.ok {
+			t.Errorf("%s/%s: expected %t, got %t", tt.user, tt.pass, tt.ok, got)
+		}
+	}
+}
+
+func TestNewBasicAuthFromFileMalformed(t *testing.T) {
+	for _, line := range []string{
+		"nocolon",
+		"bob:",
+		":$apr1$saltsalt$yAAkm4libquA.ZWLHbSBq/",
+		"bob:plaintext",
+		"bob:$2y$notbcrypt",
+	} {
+		path := writeHtpasswd(t, "alice:$apr1$saltsalt$yAAkm4libquA.ZWLHbSBq/", line)
+		_, err := NewBasicAuthFromFile(path)
+		if err == nil {
+			t.Errorf("Expected an error for line %q", line)
+		} else if !strings.Contains(err.Error(), "line 2") {
+			t.Errorf("Expected the error for line %q to name the line, got: %s", line, err)
+		}
+	}
+
+	if _, err := NewBasicAuthFromFile(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+func TestBasicAuthReload(t *testing.T) {
+	path := writeHtpasswd(t, "alice:$apr1$saltsalt$yAAkm4libquA.ZWLHbSBq/")
+	ba, err := NewBasicAuthFromFile(path)
+	if err != nil {
+		t.Fatalf("Unable to load htpasswd file: %s", err)
+	}
+
+	if err := os.WriteFile(path, []byte("carol:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=\n"), 0600); err != nil {
+		t.Fatalf("Unable to rewrite htpasswd file: %s", err)
+	}
+	if err := ba.Reload(); err != nil {
+		t.Fatalf("Unable to reload htpasswd file: %s", err)
+	}
+	if basicAuthOK(t, ba, "alice", "password") {
+		t.Error("Expected removed user to be rejected after reload")
+	}
+	if !basicAuthOK(t, ba, "carol", "password") {
+		t.Error("Expected added user to be accepted after reload")
+	}
+
+	if err := os.WriteFile(path, []byte("broken\n"), 0600); err != nil {
+		t.Fatalf("Unable to rewrite htpasswd file: %s", err)
+	}
+	if err := ba.Reload(); err == nil {
+		t.Error("Expected an error reloading a malformed file")
+	}
+	if !basicAuthOK(t, ba, "carol", "password") {
+		t.Error("Expected the previous principals to be kept after a failed reload")
+	}
+
+	if err := NewBasicAuth().Reload(); err == nil {
+		t.Error("Expected an error reloading a BasicAuth not loaded from a file")
+	}
+}
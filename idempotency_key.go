@@ -0,0 +1,40 @@
+package authenticater
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// IdempotencyKey ensures that requests using one of a set of methods carry a
+// UUID formatted Idempotency-Key header. Requests using other methods are
+// always authenticated, so it is meant to be combined with a real
+// Authenticater using And. Storing and honoring the keys is left to the
+// handler.
+type IdempotencyKey struct {
+	methods map[string]struct{}
+}
+
+// NewIdempotencyKey returns an IdempotencyKey requiring the header for the
+// given methods, or for POST and PUT if none are given.
+func NewIdempotencyKey(methods ...string) *IdempotencyKey {
+	if len(methods) == 0 {
+		methods = []string{"POST", "PUT"}
+	}
+	ik := &IdempotencyKey{methods: make(map[string]struct{}, len(methods))}
+	for _, m := range methods {
+		ik.methods[strings.ToUpper(m)] = struct{}{}
+	}
+	return ik
+}
+
+// Authenticate the request if its method doesn't require an idempotency key
+// or it carries a well formed one.
+func (ik *IdempotencyKey) Authenticate(r *http.Request) bool {
+	if _, required := ik.methods[r.Method]; !required {
+		return true
+	}
+	return uuidPattern.MatchString(r.Header.Get("Idempotency-Key"))
+}
@@ -0,0 +1,44 @@
+package authenticater
+
+import "testing"
+
+func TestIdempotencyKey(t *testing.T) {
+	ik := NewIdempotencyKey()
+
+	tests := []struct {
+		method, key string
+		ok          bool
+	}{
+		{"POST", "7c9e6679-7425-40de-944b-e07fc1f90ae7", true},
+		{"PUT", "7C9E6679-7425-40DE-944B-E07FC1F90AE7", true},
+		{"POST", "", false},
+		{"POST", "not-a-uuid", false},
+		{"PUT", "7c9e6679742540de944be07fc1f90ae7", false},
+		{"GET", "", true},
+		{"DELETE", "", true},
+	}
+
+	for _, tt := range tests {
+		r := newTestRequest(t, tt.method, "/charges", "")
+		if tt.key != "" {
+			r.Header.Set("Idempotency-Key", tt.key)
+		}
+		if got := ik.Authenticate(r); got != tt.ok {
+			t.Errorf("%s with key %q: expected %t, got %t", tt.method, tt.key, tt.ok, got)
+		}
+	}
+}
+
+func TestIdempotencyKeyMethods(t *testing.T) {
+	ik := NewIdempotencyKey("patch")
+
+	r := newTestRequest(t, "PATCH", "/charges/1", "")
+	if ik.Authenticate(r) {
+		t.Error("Expected PATCH without a key to be denied")
+	}
+
+	r.Method = "POST"
+	if !ik.Authenticate(r) {
+		t.Error("Expected POST to be unaffected when only PATCH is configured")
+	}
+}
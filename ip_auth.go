@@ -0,0 +1,116 @@
+package authenticater
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// IPAuth ensures that the client IP of a request falls within one of a set of
+// allowed ranges and is safe for concurrent use.
+//
+// By default the client IP is the address of the peer that connected to us.
+// With TrustForwardedFor set it is instead taken from X-Forwarded-For: the
+// addresses in the header followed by the peer's are walked from right to
+// left, skipping trusted proxies, and the first address that isn't a trusted
+// proxy is the client. Addresses to the left of it were supplied by the
+// client and can't be trusted. With no trusted proxies configured, the peer
+// itself is assumed to be the only proxy, as on Heroku.
+type IPAuth struct {
+	sync.RWMutex
+	allowed []*net.IPNet
+	proxies []*net.IPNet
+
+	// TrustForwardedFor resolves the client IP from X-Forwarded-For. Only set
+	// it when every request arrives through a proxy that appends to it.
+	TrustForwardedFor bool
+}
+
+// NewIPAuth returns an IPAuth that denies every request until ranges are
+// added.
+func NewIPAuth() *IPAuth {
+	return &IPAuth{}
+}
+
+// AddCIDR allows requests from the CIDR range, e.g. 192.0.2.0/24.
+func (ia *IPAuth) AddCIDR(cidr string) error {
+	_, ipnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return err
+	}
+	ia.Lock()
+	ia.allowed = append(ia.allowed, ipnet)
+	ia.Unlock()
+	return nil
+}
+
+// AddTrustedProxy marks the CIDR range as proxies whose X-Forwarded-For
+// entries are trusted when TrustForwardedFor is set.
+func (ia *IPAuth) AddTrustedProxy(cidr string) error {
+	_, ipnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return err
+	}
+	ia.Lock()
+	ia.proxies = append(ia.proxies, ipnet)
+	ia.Unlock()
+	return nil
+}
+
+// Authenticate the request if its client IP is in an allowed range.
+func (ia *IPAuth) Authenticate(r *http.Request) bool {
+	ia.RLock()
+	defer ia.RUnlock()
+
+	ip := ia.clientIP(r)
+	return ip != nil && containsIP(ia.allowed, ip)
+}
+
+func (ia *IPAuth) clientIP(r *http.Request) net.IP {
+	peer := remoteIP(r)
+	if !ia.TrustForwardedFor || peer == nil {
+		return peer
+	}
+	if len(ia.proxies) > 0 && !containsIP(ia.proxies, peer) {
+		return peer
+	}
+
+	var forwarded []string
+	for _, h := range r.Header.Values("X-Forwarded-For") {
+		forwarded = append(forwarded, strings.Split(h, ",")...)
+	}
+	if len(forwarded) == 0 {
+		return peer
+	}
+
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if ip == nil {
+			return nil
+		}
+		if i == 0 || !containsIP(ia.proxies, ip) {
+			return ip
+		}
+	}
+	return nil
+}
+
+// remoteIP returns the IP address of the peer that connected to us, or nil if
+// r.RemoteAddr can't be parsed.
+func remoteIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
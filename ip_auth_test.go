@@ -0,0 +1,109 @@
+package authenticater
+
+import (
+	"net/http"
+	"testing"
+)
+
+func newIPRequest(t *testing.T, remoteAddr string, xff ...string) *http.Request {
+	r := newTestRequest(t, "GET", "/admin", "")
+	r.RemoteAddr = remoteAddr
+	for _, v := range xff {
+		r.Header.Add("X-Forwarded-For", v)
+	}
+	return r
+}
+
+func TestIPAuth(t *testing.T) {
+	ia := NewIPAuth()
+	if err := ia.AddCIDR("192.0.2.0/24"); err != nil {
+		t.Fatalf("Unable to add CIDR: %s", err)
+	}
+	if err := ia.AddCIDR("2001:db8::/32"); err != nil {
+		t.Fatalf("Unable to add CIDR: %s", err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		xff        []string
+		ok         bool
+	}{
+		{"allowed v4", "192.0.2.10:5000", nil, true},
+		{"allowed v6", "[2001:db8::1]:5000", nil, true},
+		{"denied", "198.51.100.1:5000", nil, false},
+		{"forwarded for ignored", "198.51.100.1:5000", []string{"192.0.2.10"}, false},
+		{"unparseable", "nope", nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := ia.Authenticate(newIPRequest(t, tt.remoteAddr, tt.xff...)); got != tt.ok {
+			t.Errorf("%s: expected %t, got %t", tt.name, tt.ok, got)
+		}
+	}
+}
+
+func TestIPAuthForwardedFor(t *testing.T) {
+	ia := NewIPAuth()
+	ia.TrustForwardedFor = true
+	if err := ia.AddCIDR("192.0.2.0/24"); err != nil {
+		t.Fatalf("Unable to add CIDR: %s", err)
+	}
+
+	tests := []struct {
+		name string
+		xff  []string
+		ok   bool
+	}{
+		{"allowed client", []string{"192.0.2.10"}, true},
+		{"denied client", []string{"198.51.100.1"}, false},
+		{"spoofed leftmost entry", []string{"192.0.2.10, 198.51.100.1"}, false},
+		{"no header uses peer", nil, false},
+		{"garbage", []string{"nope"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := ia.Authenticate(newIPRequest(t, "10.0.0.1:5000", tt.xff...)); got != tt.ok {
+			t.Errorf("%s: expected %t, got %t", tt.name, tt.ok, got)
+		}
+	}
+}
+
+func TestIPAuthTrustedProxies(t *testing.T) {
+	ia := NewIPAuth()
+	ia.TrustForwardedFor = true
+	if err := ia.AddCIDR("192.0.2.0/24"); err != nil {
+		t.Fatalf("Unable to add CIDR: %s", err)
+	}
+	if err := ia.AddTrustedProxy("10.0.0.0/8"); err != nil {
+		t.Fatalf("Unable to add trusted proxy: %s", err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		xff        []string
+		ok         bool
+	}{
+		{"through two proxies", "10.0.0.1:5000", []string{"192.0.2.10, 10.0.0.2"}, true},
+		{"across headers", "10.0.0.1:5000", []string{"192.0.2.10", "10.0.0.2"}, true},
+		{"spoofed beyond client", "10.0.0.1:5000", []string{"192.0.2.10, 198.51.100.1, 10.0.0.2"}, false},
+		{"untrusted peer", "198.51.100.1:5000", []string{"192.0.2.10"}, false},
+		{"only proxies", "10.0.0.1:5000", []string{"10.0.0.3, 10.0.0.2"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := ia.Authenticate(newIPRequest(t, tt.remoteAddr, tt.xff...)); got != tt.ok {
+			t.Errorf("%s: expected %t, got %t", tt.name, tt.ok, got)
+		}
+	}
+}
+
+func TestIPAuthBadCIDR(t *testing.T) {
+	if err := NewIPAuth().AddCIDR("192.0.2.0"); err == nil {
+		t.Error("Expected an error for an invalid CIDR")
+	}
+	if err := NewIPAuth().AddTrustedProxy("10.0.0.0/33"); err == nil {
+		t.Error("Expected an error for an invalid trusted proxy CIDR")
+	}
+}
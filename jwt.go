@@ -0,0 +1,217 @@
+package authenticater
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	// Register the hashes used by the supported algorithms.
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+// Claims are the claims of a validated JWT, as decoded from JSON.
+type Claims map[string]interface{}
+
+type claimsKey struct{}
+
+// GetClaims returns the claims of the JWT that authenticated the request, when
+// the handler was wrapped by WrapAuth with a JWTAuth.
+func GetClaims(r *http.Request) (Claims, bool) {
+	c, ok := r.Context().Value(claimsKey{}).(Claims)
+	return c, ok
+}
+
+var jwtHashes = map[string]crypto.Hash{
+	"256": crypto.SHA256,
+	"384": crypto.SHA384,
+	"512": crypto.SHA512,
+}
+
+// JWTAuth ensures that the request carries a JWT as a Bearer token that is
+// signed with the configured key, has not expired and, if configured, was
+// issued by Issuer for Audience. Tokens must have an exp claim; an nbf claim
+// is honored if present.
+//
+// Only algorithms matching the key's type are accepted: HS256/384/512 for an
+// HMAC secret, RS256/384/512 for an RSA key and ES256/384/512 for an ECDSA
+// key.
+type JWTAuth struct {
+	key interface{}
+	alg string
+
+	// Issuer, if set, must equal the token's iss claim.
+	Issuer string
+
+	// Audience, if set, must be one of the token's aud claim values.
+	Audience string
+
+	now func() time.Time
+}
+
+// NewJWTAuthHMAC returns a JWTAuth validating HMAC signed tokens with secret.
+func NewJWTAuthHMAC(secret []byte) *JWTAuth {
+	return &JWTAuth{key: secret, alg: "HS", now: time.Now}
+}
+
+// NewJWTAuthRSA returns a JWTAuth validating RSA signed tokens with key.
+func NewJWTAuthRSA(key *rsa.PublicKey) *JWTAuth {
+	return &JWTAuth{key: key, alg: "RS", now: time.Now}
+}
+
+// NewJWTAuthECDSA returns a JWTAuth validating ECDSA signed tokens with key.
+func NewJWTAuthECDSA(key *ecdsa.PublicKey) *JWTAuth {
+	return &JWTAuth{key: key, alg: "ES", now: time.Now}
+}
+
+// Authenticate the request if its Bearer token is a valid JWT.
+func (ja *JWTAuth) Authenticate(r *http.Request) bool {
+	_, ok := ja.AuthenticateContext(r)
+	return ok
+}
+
+// AuthenticateContext is like Authenticate but also returns the request's
+// context with the token's claims added, for GetClaims.
+func (ja *JWTAuth) AuthenticateContext(r *http.Request) (context.Context, bool) {
+	token, ok := BearerToken(r)
+	if !ok {
+		return r.Context(), false
+	}
+	claims, err := ja.Validate(token)
+	if err != nil {
+		return r.Context(), false
+	}
+	return context.WithValue(r.Context(), claimsKey{}, claims), true
+}
+
+// Challenge returns a Bearer WWW-Authenticate challenge.
+func (ja *JWTAuth) Challenge() string {
+	return "Bearer"
+}
+
+// Validate checks token's signature and claims, returning the claims if it is
+// valid.
+func (ja *JWTAuth) Validate(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("Malformed JWT")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, err
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	if err := ja.verify(header.Alg, parts[0]+"."+parts[1], sig); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if err := ja.validateClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func (ja *JWTAuth) verify(alg, signed string, sig []byte) error {
+	if len(alg) != 5 || alg[:2] != ja.alg {
+		return fmt.Errorf("Unexpected JWT algorithm '%s'", alg)
+	}
+	hash, ok := jwtHashes[alg[2:]]
+	if !ok {
+		return fmt.Errorf("Unexpected JWT algorithm '%s'", alg)
+	}
+
+	if secret, ok := ja.key.([]byte); ok {
+		mac := hmac.New(hash.New, secret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(sig, mac.Sum(nil)) {
+			return errors.New("Invalid JWT signature")
+		}
+		return nil
+	}
+
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	switch key := ja.key.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, hash, digest, sig)
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return errors.New("Invalid JWT signature")
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			return errors.New("Invalid JWT signature")
+		}
+		return nil
+	}
+	return errors.New("Unsupported JWT key")
+}
+
+func (ja *JWTAuth) validateClaims(claims Claims) error {
+	now := ja.now()
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return errors.New("JWT has no exp claim")
+	}
+	if !now.Before(time.Unix(int64(exp), 0)) {
+		return errors.New("JWT has expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Before(time.Unix(int64(nbf), 0)) {
+		return errors.New("JWT is not valid yet")
+	}
+
+	if ja.Issuer != "" && claims["iss"] != ja.Issuer {
+		return errors.New("Unexpected JWT issuer")
+	}
+
+	if ja.Audience != "" {
+		switch aud := claims["aud"].(type) {
+		case string:
+			if aud == ja.Audience {
+				return nil
+			}
+		case []interface{}:
+			for _, a := range aud {
+				if a == ja.Audience {
+					return nil
+				}
+			}
+		}
+		return errors.New("Unexpected JWT audience")
+	}
+	return nil
+}
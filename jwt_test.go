@@ -0,0 +1,174 @@
+package authenticater
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var jwtTestNow = time.Unix(1500000000, 0)
+
+// signJWT builds a token with the given claims signed with key using alg.
+func signJWT(t *testing.T, alg string, key interface{}, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("Unable to encode claims: %s", err)
+	}
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	hash := jwtHashes[alg[2:]]
+	var sig []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(hash.New, k)
+		mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		h := hash.New()
+		h.Write([]byte(signed))
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k, hash, h.Sum(nil))
+	case *ecdsa.PrivateKey:
+		h := hash.New()
+		h.Write([]byte(signed))
+		r, s, serr := ecdsa.Sign(rand.Reader, k, h.Sum(nil))
+		size := (k.Curve.Params().BitSize + 7) / 8
+		sig = make([]byte, 2*size)
+		r.FillBytes(sig[:size])
+		s.FillBytes(sig[size:])
+		err = serr
+	}
+	if err != nil {
+		t.Fatalf("Unable to sign token: %s", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func jwtClaims(extra map[string]interface{}) map[string]interface{} {
+	claims := map[string]interface{}{
+		"sub": "user-1",
+		"iss": "https://id.example.com",
+		"aud": "api",
+		"exp": jwtTestNow.Add(time.Hour).Unix(),
+	}
+	for k, v := range extra {
+		if v == nil {
+			delete(claims, k)
+		} else {
+			claims[k] = v
+		}
+	}
+	return claims
+}
+
+func TestJWTAuthHMAC(t *testing.T) {
+	secret := []byte("secret")
+	ja := NewJWTAuthHMAC(secret)
+	ja.Issuer = "https://id.example.com"
+	ja.Audience = "api"
+	ja.now = func() time.Time { return jwtTestNow }
+
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"valid", signJWT(t, "HS256", secret, jwtClaims(nil)), true},
+		{"valid HS512", signJWT(t, "HS512", secret, jwtClaims(nil)), true},
+		{"audience list", signJWT(t, "HS256", secret, jwtClaims(map[string]interface{}{"aud": []string{"web", "api"}})), true},
+		{"wrong secret", signJWT(t, "HS256", []byte("wrong"), jwtClaims(nil)), false},
+		{"expired", signJWT(t, "HS256", secret, jwtClaims(map[string]interface{}{"exp": jwtTestNow.Unix()})), false},
+		{"no exp", signJWT(t, "HS256", secret, jwtClaims(map[string]interface{}{"exp": nil})), false},
+		{"not yet valid", signJWT(t, "HS256", secret, jwtClaims(map[string]interface{}{"nbf": jwtTestNow.Add(time.Minute).Unix()})), false},
+		{"already valid", signJWT(t, "HS256", secret, jwtClaims(map[string]interface{}{"nbf": jwtTestNow.Unix()})), true},
+		{"wrong issuer", signJWT(t, "HS256", secret, jwtClaims(map[string]interface{}{"iss": "https://evil.example.com"})), false},
+		{"wrong audience", signJWT(t, "HS256", secret, jwtClaims(map[string]interface{}{"aud": "web"})), false},
+		{"no audience", signJWT(t, "HS256", secret, jwtClaims(map[string]interface{}{"aud": nil})), false},
+		{"alg none", "eyJhbGciOiJub25lIn0.eyJleHAiOjE1MDAwMDM2MDB9.", false},
+		{"malformed", "not.a.jwt", false},
+	}
+
+	for _, tt := range tests {
+		if got := ja.Authenticate(newBearerRequest(t, tt.token)); got != tt.ok {
+			t.Errorf("%s: expected %t, got %t", tt.name, tt.ok, got)
+		}
+	}
+}
+
+func TestJWTAuthRSA(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Unable to generate key: %s", err)
+	}
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Unable to generate key: %s", err)
+	}
+
+	ja := NewJWTAuthRSA(&key.PublicKey)
+	ja.now = func() time.Time { return jwtTestNow }
+
+	if !ja.Authenticate(newBearerRequest(t, signJWT(t, "RS256", key, jwtClaims(nil)))) {
+		t.Error("Expected a valid RS256 token to be accepted")
+	}
+	if ja.Authenticate(newBearerRequest(t, signJWT(t, "RS256", other, jwtClaims(nil)))) {
+		t.Error("Expected a token signed by another key to be rejected")
+	}
+
+	// An HS256 token "signed" with the public key must not be accepted.
+	pub, _ := json.Marshal(key.PublicKey)
+	if ja.Authenticate(newBearerRequest(t, signJWT(t, "HS256", pub, jwtClaims(nil)))) {
+		t.Error("Expected an algorithm that doesn't match the key to be rejected")
+	}
+}
+
+func TestJWTAuthECDSA(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Unable to generate key: %s", err)
+	}
+
+	ja := NewJWTAuthECDSA(&key.PublicKey)
+	ja.now = func() time.Time { return jwtTestNow }
+
+	if !ja.Authenticate(newBearerRequest(t, signJWT(t, "ES256", key, jwtClaims(nil)))) {
+		t.Error("Expected a valid ES256 token to be accepted")
+	}
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Unable to generate key: %s", err)
+	}
+	if ja.Authenticate(newBearerRequest(t, signJWT(t, "ES256", other, jwtClaims(nil)))) {
+		t.Error("Expected a token signed by another key to be rejected")
+	}
+}
+
+func TestJWTAuthClaimsInContext(t *testing.T) {
+	secret := []byte("secret")
+	ja := NewJWTAuthHMAC(secret)
+	ja.now = func() time.Time { return jwtTestNow }
+
+	var claims Claims
+	h := WrapAuth(ja, func(w http.ResponseWriter, r *http.Request) {
+		claims, _ = GetClaims(r)
+	})
+
+	h(httptest.NewRecorder(), newBearerRequest(t, signJWT(t, "HS256", secret, jwtClaims(nil))))
+	if claims == nil || claims["sub"] != "user-1" {
+		t.Errorf("Expected the handler to see the token's claims, got %v", claims)
+	}
+
+	w := httptest.NewRecorder()
+	h(w, newBearerRequest(t, "invalid"))
+	if w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") != "Bearer" {
+		t.Errorf("Expected a 401 with a Bearer challenge, got %d %q", w.Code, w.Header().Get("WWW-Authenticate"))
+	}
+}
@@ -0,0 +1,115 @@
+package authenticater
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// OneTimeTokenStore stores the tokens minted by a OneTimeToken. Consume must be
+// atomic so that a token can never be consumed twice.
+type OneTimeTokenStore interface {
+	// Add stores token as valid until expires.
+	Add(token string, expires time.Time) error
+
+	// Consume removes token and reports whether it was stored and had not
+	// expired at now.
+	Consume(token string, now time.Time) (bool, error)
+}
+
+// MemoryOneTimeTokenStore is an in-process OneTimeTokenStore that is safe for
+// concurrent use.
+type MemoryOneTimeTokenStore struct {
+	sync.Mutex
+	tokens map[string]time.Time
+}
+
+// NewMemoryOneTimeTokenStore returns an empty MemoryOneTimeTokenStore.
+func NewMemoryOneTimeTokenStore() *MemoryOneTimeTokenStore {
+	return &MemoryOneTimeTokenStore{tokens: make(map[string]time.Time)}
+}
+
+// Add stores token until expires, dropping any tokens that have already
+// expired.
+func (s *MemoryOneTimeTokenStore) Add(token string, expires time.Time) error {
+	s.Lock()
+	defer s.Unlock()
+	now := time.Now()
+	for t, e := range s.tokens {
+		if !now.Before(e) {
+			delete(s.tokens, t)
+		}
+	}
+	s.tokens[token] = expires
+	return nil
+}
+
+// Consume removes token, reporting whether it existed and was unexpired.
+func (s *MemoryOneTimeTokenStore) Consume(token string, now time.Time) (bool, error) {
+	s.Lock()
+	defer s.Unlock()
+	expires, exists := s.tokens[token]
+	delete(s.tokens, token)
+	return exists && now.Before(expires), nil
+}
+
+// OneTimeToken ensures that the request carries a token minted by Mint that
+// has not been used before and has not expired, e.g. for magic links. The
+// token is consumed by the first request presenting it.
+type OneTimeToken struct {
+	Store OneTimeTokenStore
+
+	// Param is the query parameter holding the token.
+	Param string
+
+	// TTL is how long a minted token remains valid.
+	TTL time.Duration
+
+	now func() time.Time
+}
+
+// NewOneTimeToken returns a OneTimeToken backed by store, reading the token
+// from the "token" query parameter, whose tokens are valid for ttl. A nil store
+// defaults to a MemoryOneTimeTokenStore.
+func NewOneTimeToken(store OneTimeTokenStore, ttl time.Duration) *OneTimeToken {
+	if store == nil {
+		store = NewMemoryOneTimeTokenStore()
+	}
+	return &OneTimeToken{
+		Store: store,
+		Param: "token",
+		TTL:   ttl,
+		now:   time.Now,
+	}
+}
+
+// Mint creates, stores and returns a new random token.
+func (ott *OneTimeToken) Mint() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	if err := ott.Store.Add(token, ott.now().Add(ott.TTL)); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Authenticate the request if it carries an unused, unexpired token,
+// consuming it.
+func (ott *OneTimeToken) Authenticate(r *http.Request) bool {
+	ok, err := ott.AuthenticateErr(r)
+	return ok && err == nil
+}
+
+// AuthenticateErr is like Authenticate but returns the Store's error.
+func (ott *OneTimeToken) AuthenticateErr(r *http.Request) (bool, error) {
+	token := r.URL.Query().Get(ott.Param)
+	if token == "" {
+		return false, nil
+	}
+	return ott.Store.Consume(token, ott.now())
+}
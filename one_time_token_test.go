@@ -0,0 +1,79 @@
+package authenticater
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestOneTimeToken(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	ott := NewOneTimeToken(NewMemoryOneTimeTokenStore(), time.Minute)
+	ott.now = func() time.Time { return now }
+
+	token, err := ott.Mint()
+	if err != nil {
+		t.Fatalf("Unable to mint token: %s", err)
+	}
+
+	if !ott.Authenticate(newTestRequest(t, "GET", "/login?token="+url.QueryEscape(token), "")) {
+		t.Error("Expected first use of the token to succeed")
+	}
+	if ott.Authenticate(newTestRequest(t, "GET", "/login?token="+url.QueryEscape(token), "")) {
+		t.Error("Expected replay of the token to fail")
+	}
+
+	expired, err := ott.Mint()
+	if err != nil {
+		t.Fatalf("Unable to mint token: %s", err)
+	}
+	now = now.Add(time.Minute)
+	if ott.Authenticate(newTestRequest(t, "GET", "/login?token="+url.QueryEscape(expired), "")) {
+		t.Error("Expected an expired token to fail")
+	}
+
+	if ott.Authenticate(newTestRequest(t, "GET", "/login?token="+url.QueryEscape("unknown"), "")) {
+		t.Error("Expected an unknown token to fail")
+	}
+	if ott.Authenticate(newTestRequest(t, "GET", "/login?token="+url.QueryEscape(""), "")) {
+		t.Error("Expected a missing token to fail")
+	}
+}
+
+func TestOneTimeTokenNilStore(t *testing.T) {
+	ott := NewOneTimeToken(nil, time.Minute)
+	token, err := ott.Mint()
+	if err != nil {
+		t.Fatalf("Unable to mint token: %s", err)
+	}
+	if !ott.Authenticate(newTestRequest(t, "GET", "/login?token="+url.QueryEscape(token), "")) {
+		t.Error("Expected a token from the default store to succeed")
+	}
+}
+
+// run with -race
+func TestOneTimeTokenConsumedOnce(t *testing.T) {
+	ott := NewOneTimeToken(NewMemoryOneTimeTokenStore(), time.Minute)
+	token, err := ott.Mint()
+	if err != nil {
+		t.Fatalf("Unable to mint token: %s", err)
+	}
+
+	results := make(chan bool)
+	for i := 0; i < 50; i++ {
+		r := newTestRequest(t, "GET", "/login?token="+url.QueryEscape(token), "")
+		go func() {
+			results <- ott.Authenticate(r)
+		}()
+	}
+
+	successes := 0
+	for i := 0; i < 50; i++ {
+		if <-results {
+			successes++
+		}
+	}
+	if successes != 1 {
+		t.Errorf("Expected exactly one successful use, got %d", successes)
+	}
+}
@@ -0,0 +1,95 @@
+package authenticater
+
+import (
+	"net/http"
+	"path"
+	"sync"
+)
+
+// PathRealms picks the Basic Auth realm, and optionally the Authenticater, for
+// a request by the longest path prefix added with AddRealm that matches the
+// request path, so that e.g. the public API and the admin area of a service
+// prompt for different realms. Requests matching no prefix use the default
+// Authenticater and its challenge. It is safe for concurrent use.
+type PathRealms struct {
+	sync.RWMutex
+	def   Authenticater
+	areas map[string]pathRealm
+}
+
+type pathRealm struct {
+	realm string
+	auth  Authenticater
+}
+
+// NewPathRealms returns a PathRealms without any prefixes, authenticating
+// every request with def.
+func NewPathRealms(def Authenticater) *PathRealms {
+	return &PathRealms{
+		def:   def,
+		areas: make(map[string]pathRealm),
+	}
+}
+
+// AddRealm sends realm in the challenge for requests to prefix and the paths
+// below it, e.g. "/admin" covers "/admin" and "/admin/users" but not
+// "/administrator". Those requests are authenticated with auth, so the area
+// can have its own credentials, or with the default Authenticater if auth is
+// nil.
+func (pr *PathRealms) AddRealm(prefix, realm string, auth Authenticater) {
+	if auth == nil {
+		auth = pr.def
+	}
+	pr.Lock()
+	defer pr.Unlock()
+	pr.areas[path.Clean("/"+prefix)] = pathRealm{realm: realm, auth: auth}
+}
+
+// Authenticate is true if the Authenticater for the request's path
+// authenticates it.
+func (pr *PathRealms) Authenticate(r *http.Request) bool {
+	ok, err := pr.AuthenticateErr(r)
+	return ok && err == nil
+}
+
+// AuthenticateErr returns the error of the Authenticater for the request's
+// path if it is an ErrAuthenticater.
+func (pr *PathRealms) AuthenticateErr(r *http.Request) (bool, error) {
+	area, _ := pr.area(r.URL.Path)
+	return authenticate(area.auth, r)
+}
+
+// ChallengeRequest returns a Basic challenge for the realm of the request's
+// path. Requests matching no prefix get the default Authenticater's challenge
+// if it is a Challenger, and one for DefaultRealm otherwise.
+func (pr *PathRealms) ChallengeRequest(r *http.Request) string {
+	area, ok := pr.area(r.URL.Path)
+	if !ok {
+		if c, isC := pr.def.(Challenger); isC {
+			return c.Challenge()
+		}
+	}
+	realm := area.realm
+	if realm == "" {
+		realm = DefaultRealm
+	}
+	return `Basic realm="` + realmEscaper.Replace(realm) + `"`
+}
+
+// area returns the realm and Authenticater of the longest prefix matching p,
+// and false with the default Authenticater if none does.
+func (pr *PathRealms) area(p string) (pathRealm, bool) {
+	p = path.Clean("/" + p)
+
+	pr.RLock()
+	defer pr.RUnlock()
+	for {
+		if area, ok := pr.areas[p]; ok {
+			return area, true
+		}
+		if p == "/" {
+			return pathRealm{auth: pr.def}, false
+		}
+		p = path.Dir(p)
+	}
+}
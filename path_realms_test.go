@@ -0,0 +1,89 @@
+package authenticater
+
+import (
+	"net/http"
+	"testing"
+)
+
+func newPathRealms(t *testing.T) *PathRealms {
+	shared, err := NewBasicAuthFromString("user:pass")
+	if err != nil {
+		t.Fatalf("Unable to construct basic auth checker: %s", err)
+	}
+	shared.Realm = "Example"
+	admin, err := NewBasicAuthFromString("admin:secret")
+	if err != nil {
+		t.Fatalf("Unable to construct basic auth checker: %s", err)
+	}
+
+	pr := NewPathRealms(shared)
+	pr.AddRealm("/api", "Public API", nil)
+	pr.AddRealm("/admin/", "Admin", admin)
+	pr.AddRealm("/admin/billing", `Billing "Admin"`, admin)
+	return pr
+}
+
+func TestPathRealmsChallenge(t *testing.T) {
+	pr := newPathRealms(t)
+
+	tests := []struct {
+		path      string
+		challenge string
+	}{
+		{"/", `Basic realm="Example"`},
+		{"/api", `Basic realm="Public API"`},
+		{"/api/apps/1", `Basic realm="Public API"`},
+		{"/apis", `Basic realm="Example"`},
+		{"/admin", `Basic realm="Admin"`},
+		{"/admin/users", `Basic realm="Admin"`},
+		{"/admin/billing/invoices", `Basic realm="Billing \"Admin\""`},
+		{"/administrator", `Basic realm="Example"`},
+		{"/api/../admin/users", `Basic realm="Admin"`},
+	}
+
+	for _, tt := range tests {
+		w, called := serveWrapped(t, pr, func(r *http.Request) { r.URL.Path = tt.path })
+		if called || w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected 401 without calling the handler, got %d (called = %t)", tt.path, w.Code, called)
+		}
+		if got := w.Header().Get("WWW-Authenticate"); got != tt.challenge {
+			t.Errorf("%s: expected challenge %s, got %s", tt.path, tt.challenge, got)
+		}
+	}
+}
+
+func TestPathRealmsCredentials(t *testing.T) {
+	pr := newPathRealms(t)
+
+	tests := []struct {
+		path, user, pass string
+		ok               bool
+	}{
+		{"/", "user", "pass", true},
+		{"/api/apps", "user", "pass", true},
+		{"/api/apps", "admin", "secret", false},
+		{"/admin/users", "admin", "secret", true},
+		{"/admin/users", "user", "pass", false},
+		{"/administrator", "user", "pass", true},
+	}
+
+	for _, tt := range tests {
+		r := newTestRequest(t, "GET", tt.path, "")
+		r.SetBasicAuth(tt.user, tt.pass)
+		if got := pr.Authenticate(r); got != tt.ok {
+			t.Errorf("%s %s/%s: expected %t, got %t", tt.path, tt.user, tt.pass, tt.ok, got)
+		}
+	}
+}
+
+func TestPathRealmsDefaultRealm(t *testing.T) {
+	pr := NewPathRealms(NewLogplexDrainToken())
+	pr.AddRealm("/admin", "", nil)
+
+	for _, path := range []string{"/", "/admin"} {
+		r := newTestRequest(t, "GET", path, "")
+		if got := pr.ChallengeRequest(r); got != `Basic realm="Restricted"` {
+			t.Errorf("%s: expected the DefaultRealm challenge, got %s", path, got)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package authenticater
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+)
+
+// PolicyAuth authenticates requests against a JSON policy document and is
+// safe for concurrent use. A policy looks like
+//
+//	{
+//	  "default": "deny",
+//	  "deny":  [{"cidrs": ["192.0.2.0/24"]}],
+//	  "allow": [
+//	    {"paths": ["/health"], "methods": ["GET"]},
+//	    {"cidrs": ["10.0.0.0/8"], "headers": {"X-Internal": "yes"}}
+//	  ]
+//	}
+//
+// A rule matches a request when every one of its lists that is set does:
+// cidrs against the address of the peer, paths against the cleaned URL path,
+// methods case-insensitively, and headers by exact value, or by presence
+// when the value is empty. Paths are path.Match patterns, so * matches a
+// single path segment; a pattern ending in /** also matches everything below
+// it, e.g. /admin/** matches /admin, /admin/users and /admin/users/1. A
+// request matching any deny rule is rejected, otherwise one matching any
+// allow rule is authenticated, otherwise default decides. default is "allow"
+// or "deny", and "deny" if unset.
+//
+// Unknown fields and rules without conditions are rejected, so that a typo
+// can't turn a rule into one matching every request.
+type PolicyAuth struct {
+	sync.RWMutex
+	policy *policy
+}
+
+type policy struct {
+	allow        []*policyRule
+	deny         []*policyRule
+	defaultAllow bool
+}
+
+type policyRule struct {
+	nets    []*net.IPNet
+	paths   []string
+	methods map[string]struct{}
+	headers map[string]string
+}
+
+type policyDoc struct {
+	Default string          `json:"default"`
+	Allow   []policyRuleDoc `json:"allow"`
+	Deny    []policyRuleDoc `json:"deny"`
+}
+
+type policyRuleDoc struct {
+	CIDRs   []string          `json:"cidrs"`
+	Paths   []string          `json:"paths"`
+	Methods []string          `json:"methods"`
+	Headers map[string]string `json:"headers"`
+}
+
+// NewPolicyAuth returns a PolicyAuth for the JSON policy in data.
+func NewPolicyAuth(data []byte) (*PolicyAuth, error) {
+	pa := &PolicyAuth{}
+	if err := pa.SetPolicy(data); err != nil {
+		return nil, err
+	}
+	return pa, nil
+}
+
+// SetPolicy replaces the policy with the JSON policy in data. If data isn't a
+// valid policy the current one is kept.
+func (pa *PolicyAuth) SetPolicy(data []byte) error {
+	p, err := parsePolicy(data)
+	if err != nil {
+		return err
+	}
+	pa.Lock()
+	pa.policy = p
+	pa.Unlock()
+	return nil
+}
+
+// Authenticate the request if the policy allows it.
+func (pa *PolicyAuth) Authenticate(r *http.Request) bool {
+	pa.RLock()
+	p := pa.policy
+	pa.RUnlock()
+
+	for _, rule := range p.deny {
+		if rule.matches(r) {
+			return false
+		}
+	}
+	for _, rule := range p.allow {
+		if rule.matches(r) {
+			return true
+		}
+	}
+	return p.defaultAllow
+}
+
+func parsePolicy(data []byte) (*policy, error) {
+	var doc policyDoc
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("Unable to parse policy: %s", err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("Unable to parse policy: trailing data")
+	}
+
+	p := &policy{}
+	switch doc.Default {
+	case "", "deny":
+	case "allow":
+		p.defaultAllow = true
+	default:
+		return nil, fmt.Errorf("Invalid policy default %q", doc.Default)
+	}
+
+	var err error
+	if p.allow, err = compilePolicyRules("allow", doc.Allow); err != nil {
+		return nil, err
+	}
+	if p.deny, err = compilePolicyRules("deny", doc.Deny); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func compilePolicyRules(kind string, docs []policyRuleDoc) ([]*policyRule, error) {
+	rules := make([]*policyRule, 0, len(docs))
+	for i, doc := range docs {
+		if len(doc.CIDRs) == 0 && len(doc.Paths) == 0 && len(doc.Methods) == 0 && len(doc.Headers) == 0 {
+			return nil, fmt.Errorf("Invalid %s rule %d: no conditions", kind, i)
+		}
+		rule := &policyRule{paths: doc.Paths, headers: doc.Headers}
+		for _, cidr := range doc.CIDRs {
+			_, ipnet, err := net.ParseCIDR(cidr)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid %s rule %d: %s", kind, i, err)
+			}
+			rule.nets = append(rule.nets, ipnet)
+		}
+		for _, pattern := range doc.Paths {
+			if _, err := path.Match(strings.TrimSuffix(pattern, "/**"), ""); err != nil {
+				return nil, fmt.Errorf("Invalid %s rule %d: path %q: %s", kind, i, pattern, err)
+			}
+		}
+		if len(doc.Methods) > 0 {
+			rule.methods = make(map[string]struct{}, len(doc.Methods))
+			for _, m := range doc.Methods {
+				rule.methods[strings.ToUpper(m)] = struct{}{}
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func (pr *policyRule) matches(r *http.Request) bool {
+	if len(pr.nets) > 0 {
+		ip := remoteIP(r)
+		if ip == nil || !containsIP(pr.nets, ip) {
+			return false
+		}
+	}
+	if len(pr.paths) > 0 && !pr.matchesPath(r.URL.Path) {
+		return false
+	}
+	if len(pr.methods) > 0 {
+		if _, ok := pr.methods[r.Method]; !ok {
+			return false
+		}
+	}
+	for name, value := range pr.headers {
+		got, ok := r.Header[http.CanonicalHeaderKey(name)]
+		if !ok || (value != "" && got[0] != value) {
+			return false
+		}
+	}
+	return true
+}
+
+func (pr *policyRule) matchesPath(p string) bool {
+	p = path.Clean("/" + p)
+	for _, pattern := range pr.paths {
+		if ok, _ := path.Match(pattern, p); ok {
+			return true
+		}
+		if prefix := strings.TrimSuffix(pattern, "/**"); prefix != pattern {
+			if prefix == "" {
+				return true
+			}
+			for sub := p; ; sub = path.Dir(sub) {
+				if ok, _ := path.Match(prefix, sub); ok {
+					return true
+				}
+				if sub == "/" {
+					break
+				}
+			}
+		}
+	}
+	return false
+}
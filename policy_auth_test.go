@@ -0,0 +1,146 @@
+package authenticater
+
+import "testing"
+
+const testPolicy = `{
+	"default": "deny",
+	"deny": [
+		{"cidrs": ["192.0.2.0/24"]},
+		{"paths": ["/admin/*"], "methods": ["DELETE"]}
+	],
+	"allow": [
+		{"paths": ["/health"], "methods": ["get"]},
+		{"cidrs": ["10.0.0.0/8"], "headers": {"X-Internal": "yes"}},
+		{"paths": ["/admin/*"], "headers": {"X-Admin": ""}}
+	]
+}`
+
+func TestPolicyAuth(t *testing.T) {
+	pa, err := NewPolicyAuth([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("Unable to construct policy auth: %s", err)
+	}
+
+	tests := []struct {
+		name, method, path, remoteAddr string
+		headers                        map[string]string
+		ok                             bool
+	}{
+		{"allowed path and method", "GET", "/health", "198.51.100.1:5000", nil, true},
+		{"wrong method", "POST", "/health", "198.51.100.1:5000", nil, false},
+		{"deny beats allow", "GET", "/health", "192.0.2.1:5000", nil, false},
+		{"internal with header", "POST", "/jobs", "10.1.2.3:5000", map[string]string{"X-Internal": "yes"}, true},
+		{"internal with wrong header", "POST", "/jobs", "10.1.2.3:5000", map[string]string{"X-Internal": "no"}, false},
+		{"internal without header", "POST", "/jobs", "10.1.2.3:5000", nil, false},
+		{"header presence", "GET", "/admin/users", "198.51.100.1:5000", map[string]string{"X-Admin": "anything"}, true},
+		{"denied method on allowed path", "DELETE", "/admin/users", "198.51.100.1:5000", map[string]string{"X-Admin": "anything"}, false},
+		{"default", "GET", "/", "198.51.100.1:5000", nil, false},
+	}
+
+	for _, tt := range tests {
+		r := newTestRequest(t, tt.method, tt.path, "")
+		r.RemoteAddr = tt.remoteAddr
+		for k, v := range tt.headers {
+			r.Header.Set(k, v)
+		}
+		if got := pa.Authenticate(r); got != tt.ok {
+			t.Errorf("%s: expected %t, got %t", tt.name, tt.ok, got)
+		}
+	}
+}
+
+func TestPolicyAuthDefaultAllow(t *testing.T) {
+	pa, err := NewPolicyAuth([]byte(`{"default": "allow", "deny": [{"paths": ["/private"]}]}`))
+	if err != nil {
+		t.Fatalf("Unable to construct policy auth: %s", err)
+	}
+
+	if !pa.Authenticate(newTestRequest(t, "GET", "/", "")) {
+		t.Error("Expected the default to allow")
+	}
+	if pa.Authenticate(newTestRequest(t, "GET", "/private", "")) {
+		t.Error("Expected the deny rule to reject")
+	}
+}
+
+func TestPolicyAuthSetPolicy(t *testing.T) {
+	pa, err := NewPolicyAuth([]byte(`{}`))
+	if err != nil {
+		t.Fatalf("Unable to construct policy auth: %s", err)
+	}
+	r := newTestRequest(t, "GET", "/", "")
+	if pa.Authenticate(r) {
+		t.Error("Expected an empty policy to deny")
+	}
+
+	if err := pa.SetPolicy([]byte(`{"allow": [{"methods": ["GET"]}]}`)); err != nil {
+		t.Fatalf("Unable to set policy: %s", err)
+	}
+	if !pa.Authenticate(r) {
+		t.Error("Expected the new policy to allow")
+	}
+
+	if err := pa.SetPolicy([]byte(`{"allow": [{"cidrs": ["nope"]}]}`)); err == nil {
+		t.Error("Expected an error for an invalid policy")
+	}
+	if !pa.Authenticate(r) {
+		t.Error("Expected the previous policy to be kept after an invalid one")
+	}
+}
+
+func TestPolicyAuthInvalid(t *testing.T) {
+	for _, data := range []string{
+		`not json`,
+		`{"default": "maybe"}`,
+		`{"deny": [{"cidrs": ["10.0.0.0/33"]}]}`,
+		`{"allow": [{"paths": ["/[a"]}]}`,
+		`{"allow": [{"path": ["/health"]}]}`,
+		`{"allow": [{}]}`,
+		`{"deny": [{"methods": []}]}`,
+		`{} {}`,
+	} {
+		if _, err := NewPolicyAuth([]byte(data)); err == nil {
+			t.Errorf("Expected an error for policy %s", data)
+		}
+	}
+}
+
+func TestPolicyAuthPaths(t *testing.T) {
+	pa, err := NewPolicyAuth([]byte(`{
+		"default": "allow",
+		"deny": [
+			{"paths": ["/admin/**"]},
+			{"paths": ["/internal/*"]}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("Unable to construct policy auth: %s", err)
+	}
+
+	tests := []struct {
+		path string
+		ok   bool
+	}{
+		{"/admin", false},
+		{"/admin/", false},
+		{"/admin/x", false},
+		{"/admin/x/y", false},
+		{"/admin//x", false},
+		{"//admin/x", false},
+		{"/public/../admin/x", false},
+		{"/administrator", true},
+		{"/internal/x", false},
+		{"/internal//x", false},
+		{"/internal/x/y", true},
+		{"/internal", true},
+		{"/", true},
+	}
+
+	for _, tt := range tests {
+		r := newTestRequest(t, "GET", "/", "")
+		r.URL.Path = tt.path
+		if got := pa.Authenticate(r); got != tt.ok {
+			t.Errorf("%s: expected %t, got %t", tt.path, tt.ok, got)
+		}
+	}
+}
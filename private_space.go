@@ -0,0 +1,74 @@
+package authenticater
+
+import (
+	"net"
+	"net/http"
+	"sync"
+)
+
+// PrivateSpaceRouter ensures a request was delivered by a Heroku Private
+// Space router and is safe for concurrent use.
+//
+// Only the address of the peer that connected to us (r.RemoteAddr) is
+// trusted: it must fall within one of the configured router ranges and the
+// request must carry the X-Forwarded-For header the router adds. The contents
+// of X-Forwarded-For are client controlled and are never used to make the
+// decision, so a client that connects directly can't get in by forging it.
+// The optional space header is only meaningful if the router overwrites or
+// strips any client supplied value for it.
+type PrivateSpaceRouter struct {
+	sync.RWMutex
+	routers     []*net.IPNet
+	header      string
+	headerValue string
+}
+
+// NewPrivateSpaceRouter returns a PrivateSpaceRouter with no trusted router
+// ranges, which denies every request until ranges are added.
+func NewPrivateSpaceRouter() *PrivateSpaceRouter {
+	return &PrivateSpaceRouter{}
+}
+
+// AddRouterCIDR adds a CIDR range (e.g. 10.0.0.0/16) that the space's routers
+// connect from.
+func (psr *PrivateSpaceRouter) AddRouterCIDR(cidr string) error {
+	_, ipnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return err
+	}
+	psr.Lock()
+	psr.routers = append(psr.routers, ipnet)
+	psr.Unlock()
+	return nil
+}
+
+// RequireHeader additionally requires the named header to be equal to value,
+// e.g. a header identifying the space the request was routed through.
+func (psr *PrivateSpaceRouter) RequireHeader(name, value string) {
+	psr.Lock()
+	psr.header = name
+	psr.headerValue = value
+	psr.Unlock()
+}
+
+// Authenticate the request if it was forwarded to us by a trusted router and,
+// when configured, carries the expected space header.
+func (psr *PrivateSpaceRouter) Authenticate(r *http.Request) bool {
+	if r.Header.Get("X-Forwarded-For") == "" {
+		return false
+	}
+
+	ip := remoteIP(r)
+	if ip == nil {
+		return false
+	}
+
+	psr.RLock()
+	defer psr.RUnlock()
+
+	if psr.header != "" && r.Header.Get(psr.header) != psr.headerValue {
+		return false
+	}
+
+	return containsIP(psr.routers, ip)
+}
@@ -0,0 +1,59 @@
+package authenticater
+
+import "testing"
+
+func TestPrivateSpaceRouter(t *testing.T) {
+	psr := NewPrivateSpaceRouter()
+	if err := psr.AddRouterCIDR("10.1.0.0/16"); err != nil {
+		t.Fatalf("Unable to add router CIDR: %s", err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		xff        []string
+		ok         bool
+	}{
+		{"routed", "10.1.2.3:41234", []string{"203.0.113.7"}, true},
+		{"direct", "203.0.113.7:41234", nil, false},
+		{"spoofed forwarded for", "203.0.113.7:41234", []string{"203.0.113.7, 10.1.2.3"}, false},
+		{"router without forwarded for", "10.1.2.3:41234", nil, false},
+		{"unparseable remote addr", "nope", []string{"203.0.113.7"}, false},
+	}
+
+	for _, tt := range tests {
+		r := newIPRequest(t, tt.remoteAddr, tt.xff...)
+		if got := psr.Authenticate(r); got != tt.ok {
+			t.Errorf("%s: expected %t, got %t", tt.name, tt.ok, got)
+		}
+	}
+}
+
+func TestPrivateSpaceRouterHeader(t *testing.T) {
+	psr := NewPrivateSpaceRouter()
+	if err := psr.AddRouterCIDR("10.1.0.0/16"); err != nil {
+		t.Fatalf("Unable to add router CIDR: %s", err)
+	}
+	psr.RequireHeader("X-Space-Id", "space-1")
+
+	r := newIPRequest(t, "10.1.2.3:41234", "203.0.113.7")
+	if psr.Authenticate(r) {
+		t.Error("Expected request without space header to be denied")
+	}
+
+	r.Header.Set("X-Space-Id", "space-2")
+	if psr.Authenticate(r) {
+		t.Error("Expected request with wrong space header to be denied")
+	}
+
+	r.Header.Set("X-Space-Id", "space-1")
+	if !psr.Authenticate(r) {
+		t.Error("Expected request with space header to be allowed")
+	}
+}
+
+func TestPrivateSpaceRouterBadCIDR(t *testing.T) {
+	if err := NewPrivateSpaceRouter().AddRouterCIDR("10.1.0.0"); err == nil {
+		t.Error("Expected an error for an invalid CIDR")
+	}
+}
@@ -0,0 +1,24 @@
+package authenticater
+
+import "net/url"
+
+// RedactURL returns u as a string that is safe to log: the values of the
+// OAuth code and state query parameters, of any additional params and of any
+// userinfo password are replaced. u is not modified.
+func RedactURL(u *url.URL, params ...string) string {
+	c := *u
+	q := c.Query()
+	redacted := false
+	for _, p := range append([]string{"code", "state"}, params...) {
+		if vs, ok := q[p]; ok {
+			for i := range vs {
+				vs[i] = "REDACTED"
+			}
+			redacted = true
+		}
+	}
+	if redacted {
+		c.RawQuery = q.Encode()
+	}
+	return c.Redacted()
+}
@@ -0,0 +1,86 @@
+package authenticater
+
+import (
+	"testing"
+	"time"
+)
+
+func TestServiceToken(t *testing.T) {
+	key := []byte("mesh-key")
+	billing, err := NewServiceTokenAuth(key, "billing")
+	if err != nil {
+		t.Fatalf("Unable to construct service token auth: %s", err)
+	}
+	metrics, err := NewServiceTokenAuth(key, "metrics")
+	if err != nil {
+		t.Fatalf("Unable to construct service token auth: %s", err)
+	}
+
+	toBilling, err := MintServiceToken(key, "api", "billing", time.Minute)
+	if err != nil {
+		t.Fatalf("Unable to mint token: %s", err)
+	}
+	toMetrics, err := MintServiceToken(key, "billing", "metrics", time.Minute)
+	if err != nil {
+		t.Fatalf("Unable to mint token: %s", err)
+	}
+
+	if !billing.Authenticate(newBearerRequest(t, toBilling)) {
+		t.Error("Expected billing to accept a token minted for it")
+	}
+	if !metrics.Authenticate(newBearerRequest(t, toMetrics)) {
+		t.Error("Expected metrics to accept a token minted for it")
+	}
+	if metrics.Authenticate(newBearerRequest(t, toBilling)) {
+		t.Error("Expected metrics to reject a token minted for billing")
+	}
+
+	claims, err := billing.Validate(toBilling)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if claims["iss"] != "api" || claims["sub"] != "api" {
+		t.Errorf("Expected the token to identify the api service, got %v", claims)
+	}
+
+	forged, err := MintServiceToken([]byte("other-key"), "api", "billing", time.Minute)
+	if err != nil {
+		t.Fatalf("Unable to mint token: %s", err)
+	}
+	if billing.Authenticate(newBearerRequest(t, forged)) {
+		t.Error("Expected a token minted with another key to be rejected")
+	}
+}
+
+func TestServiceTokenExpiry(t *testing.T) {
+	key := []byte("mesh-key")
+	now := time.Unix(1500000000, 0)
+	billing, err := NewServiceTokenAuth(key, "billing")
+	if err != nil {
+		t.Fatalf("Unable to construct service token auth: %s", err)
+	}
+	billing.now = func() time.Time { return now }
+
+	token, err := mintServiceToken(key, "api", "billing", time.Minute, now)
+	if err != nil {
+		t.Fatalf("Unable to mint token: %s", err)
+	}
+	if !billing.Authenticate(newBearerRequest(t, token)) {
+		t.Error("Expected a fresh token to be accepted")
+	}
+
+	now = now.Add(time.Minute)
+	if billing.Authenticate(newBearerRequest(t, token)) {
+		t.Error("Expected an expired token to be rejected")
+	}
+}
+
+func TestServiceTokenEmptyAudience(t *testing.T) {
+	key := []byte("mesh-key")
+	if _, err := NewServiceTokenAuth(key, ""); err == nil {
+		t.Error("Expected an error for an empty audience")
+	}
+	if _, err := MintServiceToken(key, "api", "", time.Minute); err == nil {
+		t.Error("Expected an error minting a token without an audience")
+	}
+}
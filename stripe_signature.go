@@ -0,0 +1,97 @@
+package authenticater
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultStripeTolerance is the default maximum age of a Stripe-Signature
+// timestamp, matching Stripe's own client libraries.
+const DefaultStripeTolerance = 5 * time.Minute
+
+// StripeSignature ensures that the Stripe-Signature header of a webhook
+// contains a valid v1 signature of the request body made with the endpoint's
+// signing secret and that the signature's timestamp is recent. The request
+// body is restored so the handler can still read it.
+type StripeSignature struct {
+	secret []byte
+
+	// Tolerance is the maximum age of the signature's timestamp.
+	Tolerance time.Duration
+
+	// MaxBodySize is the largest body read to check the signature. Requests
+	// with larger bodies are denied.
+	MaxBodySize int64
+
+	now func() time.Time
+}
+
+// NewStripeSignature returns a StripeSignature Authenticator for the provided
+// webhook signing secret (whsec_...) using DefaultStripeTolerance and
+// DefaultMaxBodySize.
+func NewStripeSignature(secret string) *StripeSignature {
+	return &StripeSignature{
+		secret:      []byte(secret),
+		Tolerance:   DefaultStripeTolerance,
+		MaxBodySize: DefaultMaxBodySize,
+		now:         time.Now,
+	}
+}
+
+// Authenticate is true if the Stripe-Signature header has a timestamp within
+// the tolerance window and at least one v1 signature matching the body.
+func (ss *StripeSignature) Authenticate(r *http.Request) bool {
+	timestamp, signatures := parseStripeSignature(r.Header.Get("Stripe-Signature"))
+	if timestamp == "" || len(signatures) == 0 {
+		return false
+	}
+
+	t, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if age := ss.now().Sub(time.Unix(t, 0)); age > ss.Tolerance || age < -ss.Tolerance {
+		return false
+	}
+
+	body, err := readBody(r, ss.MaxBodySize)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, ss.secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+
+	for _, s := range signatures {
+		if sig, err := hex.DecodeString(s); err == nil && hmac.Equal(sig, expected) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseStripeSignature splits a header such as "t=123,v1=abc,v1=def" into the
+// timestamp and the v1 signatures. Other schemes are ignored.
+func parseStripeSignature(header string) (timestamp string, signatures []string) {
+	for _, part := range strings.Split(header, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "t":
+			timestamp = kv[1]
+		case "v1":
+			signatures = append(signatures, kv[1])
+		}
+	}
+	return
+}
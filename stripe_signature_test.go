@@ -0,0 +1,86 @@
+package authenticater
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+)
+
+func stripeSign(secret string, t int64, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.%s", t, payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestStripeSignature(t *testing.T) {
+	const (
+		secret  = "whsec_test"
+		payload = `{"id":"evt_1"}`
+	)
+	now := time.Unix(1500000000, 0)
+	ss := NewStripeSignature(secret)
+	ss.now = func() time.Time { return now }
+
+	fresh := now.Add(-time.Minute).Unix()
+	stale := now.Add(-DefaultStripeTolerance - time.Second).Unix()
+
+	tests := []struct {
+		name   string
+		header string
+		ok     bool
+	}{
+		{"valid", fmt.Sprintf("t=%d,v1=%s", fresh, stripeSign(secret, fresh, payload)), true},
+		{"valid among several", fmt.Sprintf("t=%d,v1=%s,v1=%s,v0=abc", fresh, stripeSign("old", fresh, payload), stripeSign(secret, fresh, payload)), true},
+		{"stale timestamp", fmt.Sprintf("t=%d,v1=%s", stale, stripeSign(secret, stale, payload)), false},
+		{"bad signature", fmt.Sprintf("t=%d,v1=%s", fresh, stripeSign("wrong", fresh, payload)), false},
+		{"signed with other timestamp", fmt.Sprintf("t=%d,v1=%s", fresh, stripeSign(secret, fresh+1, payload)), false},
+		{"missing signature", fmt.Sprintf("t=%d", fresh), false},
+		{"missing header", "", false},
+	}
+
+	for _, tt := range tests {
+		r := newTestRequest(t, "POST", "/webhook", payload)
+		r.Header.Set("Stripe-Signature", tt.header)
+		if got := ss.Authenticate(r); got != tt.ok {
+			t.Errorf("%s: expected %t, got %t", tt.name, tt.ok, got)
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("%s: unable to read restored body: %s", tt.name, err)
+		}
+		if tt.header != "" && string(body) != payload {
+			t.Errorf("%s: expected body %q to be restored, got %q", tt.name, payload, body)
+		}
+	}
+}
+
+func TestStripeSignatureMaxBodySize(t *testing.T) {
+	const secret = "whsec_test"
+	now := time.Unix(1500000000, 0)
+	ss := NewStripeSignature(secret)
+	ss.now = func() time.Time { return now }
+	ss.MaxBodySize = 8
+
+	for _, tt := range []struct {
+		payload string
+		ok      bool
+	}{
+		{`{"id":1}`, true},
+		{`{"id":12}`, false},
+	} {
+		header := fmt.Sprintf("t=%d,v1=%s", now.Unix(), stripeSign(secret, now.Unix(), tt.payload))
+		r := newTestRequest(t, "POST", "/webhook", tt.payload)
+		r.Header.Set("Stripe-Signature", header)
+		if got := ss.Authenticate(r); got != tt.ok {
+			t.Errorf("%d byte body: expected %t, got %t", len(tt.payload), tt.ok, got)
+		}
+		if body, _ := io.ReadAll(r.Body); string(body) != tt.payload {
+			t.Errorf("Expected body %q to be restored, got %q", tt.payload, body)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package authenticater
+
+import (
+	"net/http"
+	"regexp"
+	"sync"
+)
+
+// UserAgentAuth ensures that the User-Agent header matches one of a set of
+// allowed values or patterns and is safe for concurrent use. The User-Agent
+// is trivially forged, so this is only suitable as a crude filter in front of,
+// or combined with, a real Authenticater.
+type UserAgentAuth struct {
+	sync.RWMutex
+	agents   map[string]struct{}
+	patterns []*regexp.Regexp
+}
+
+// NewUserAgentAuth returns a UserAgentAuth that denies every request until
+// user agents are added.
+func NewUserAgentAuth() *UserAgentAuth {
+	return &UserAgentAuth{agents: make(map[string]struct{})}
+}
+
+// AddUserAgent allows requests whose User-Agent is exactly ua.
+func (uaa *UserAgentAuth) AddUserAgent(ua string) {
+	uaa.Lock()
+	uaa.agents[ua] = struct{}{}
+	uaa.Unlock()
+}
+
+// AddUserAgentPattern allows requests whose User-Agent matches the regular
+// expression expr. Anchor the expression to avoid matching substrings.
+func (uaa *UserAgentAuth) AddUserAgentPattern(expr string) error {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return err
+	}
+	uaa.Lock()
+	uaa.patterns = append(uaa.patterns, re)
+	uaa.Unlock()
+	return nil
+}
+
+// Authenticate the request if its User-Agent is allowed.
+func (uaa *UserAgentAuth) Authenticate(r *http.Request) bool {
+	ua := r.Header.Get("User-Agent")
+	if ua == "" {
+		return false
+	}
+
+	uaa.RLock()
+	defer uaa.RUnlock()
+
+	if _, exists := uaa.agents[ua]; exists {
+		return true
+	}
+	for _, re := range uaa.patterns {
+		if re.MatchString(ua) {
+			return true
+		}
+	}
+	return false
+}
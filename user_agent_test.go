@@ -0,0 +1,38 @@
+package authenticater
+
+import "testing"
+
+func TestUserAgentAuth(t *testing.T) {
+	uaa := NewUserAgentAuth()
+	uaa.AddUserAgent("billing-sync/1.0")
+	if err := uaa.AddUserAgentPattern(`^metrics-agent/\d+\.\d+$`); err != nil {
+		t.Fatalf("Unable to add pattern: %s", err)
+	}
+
+	tests := []struct {
+		ua string
+		ok bool
+	}{
+		{"billing-sync/1.0", true},
+		{"metrics-agent/2.13", true},
+		{"billing-sync/1.1", false},
+		{"curl/8.0.1 metrics-agent/2.13", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		r := newTestRequest(t, "GET", "/", "")
+		if tt.ua != "" {
+			r.Header.Set("User-Agent", tt.ua)
+		}
+		if got := uaa.Authenticate(r); got != tt.ok {
+			t.Errorf("User-Agent %q: expected %t, got %t", tt.ua, tt.ok, got)
+		}
+	}
+}
+
+func TestUserAgentAuthBadPattern(t *testing.T) {
+	if err := NewUserAgentAuth().AddUserAgentPattern("("); err == nil {
+		t.Error("Expected an error for an invalid pattern")
+	}
+}